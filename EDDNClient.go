@@ -1,9 +1,13 @@
 // Package EDDNClient provides an interface to EDDN.  Currently it only
 // provides subscriber support, but other features will be added in the future.
+//
+// Receiving from a ZeroMQ relay requires CGo, and libzmq.  Building with the
+// "nozmq" tag, or with CGo disabled, leaves them out, in which case only
+// websocket relays, and a ChannelInterfaceConfig.Source may be used.
 package EDDNClient
 
 // EDDNSubAddress is a simple constant for the ZeroMQ relay used by EDDN.
-const EDDNSubAddress = "tcp://eddn-relay.elite-markets.net:9500"
+const EDDNSubAddress = "tcp://eddn.edcd.io:9500"
 
 // EDDNUploadAddress is a simple constant for the EDDN POST URI.
 const EDDNUploadAddress = "http://eddn-gateway.elite-markets.net:8080/upload/"
@@ -0,0 +1,27 @@
+package EDDNClient
+
+// ApproachSettlementMessage contains the settlement data sent to EDDN when a
+// commander approaches a settlement.  Latitude, and Longitude are absent when
+// approaching an orbital settlement, and MarketID is only present for
+// settlements with a market.
+type ApproachSettlementMessage struct {
+	BodyID        int      `json:"BodyID"`              // Required
+	BodyName      string   `json:"BodyName"`            // Required
+	Event         string   `json:"event"`               // Required
+	Latitude      *float64 `json:"Latitude,omitempty"`  // Optional
+	Longitude     *float64 `json:"Longitude,omitempty"` // Optional
+	MarketID      int64    `json:"MarketID,omitempty"`  // Optional
+	Name          string   `json:"Name"`                // Required
+	StarPos       StarPos  `json:"StarPos"`             // Required
+	StarSystem    string   `json:"StarSystem"`          // Required
+	SystemAddress int64    `json:"SystemAddress"`       // Required
+	Timestamp     string   `json:"timestamp"`           // Required
+}
+
+// ApproachSettlement is the high level type that contains the entire JSON
+// message.
+type ApproachSettlement struct {
+	SchemaRef string                    `json:"$schemaRef"`
+	Header    Header                    `json:"header"`
+	Message   ApproachSettlementMessage `json:"message"`
+}
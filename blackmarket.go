@@ -1,6 +1,8 @@
 package EDDNClient
 
-// BlackmarketMessage contains the blackmarket data sent to EDDN.
+// BlackmarketMessage contains the blackmarket data sent to EDDN.  Each
+// message describes a single sale of a commodity at a station's black market,
+// and whether the commodity is prohibited there.
 type BlackmarketMessage struct {
 	Name        string `json:"name"`        // Required
 	Prohibited  bool   `json:"prohibited"`  // Required
@@ -16,3 +18,18 @@ type Blackmarket struct {
 	Header    Header             `json:"header"`
 	Message   BlackmarketMessage `json:"message"`
 }
+
+// System returns the name of the system the sale took place in.
+func (b Blackmarket) System() string {
+	return b.Message.SystemName
+}
+
+// Station returns the name of the station the sale took place at.
+func (b Blackmarket) Station() string {
+	return b.Message.StationName
+}
+
+// Sale returns the name of the commodity sold, and the price it sold for.
+func (b Blackmarket) Sale() (name string, sellPrice int) {
+	return b.Message.Name, b.Message.SellPrice
+}
@@ -0,0 +1,45 @@
+package EDDNClient
+
+import (
+	"testing"
+)
+
+const blackmarketFixture = `{
+	"$schemaRef": "http://schemas.elite-markets.net/eddn/blackmarket/1",
+	"header": {"uploaderID": "abcdef0123456789", "softwareName": "EDMC",
+		"softwareVersion": "2.4.1"},
+	"message": {
+		"systemName": "Eranin",
+		"stationName": "Azeban City",
+		"timestamp": "2016-08-07T18:02:34Z",
+		"name": "ImperialSlaves",
+		"sellPrice": 15780,
+		"prohibited": true
+	}
+}`
+
+func TestParseBlackmarket(t *testing.T) {
+	result, err := parseJSON(blackmarketFixture)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	b, ok := result.Message.(Blackmarket)
+
+	if !ok {
+		t.Fatalf("Expected Blackmarket, got %T", result.Message)
+	}
+
+	if !b.Message.Prohibited || b.Message.Timestamp != "2016-08-07T18:02:34Z" {
+		t.Errorf("Unexpected blackmarket: %+v", b.Message)
+	}
+
+	if b.System() != "Eranin" || b.Station() != "Azeban City" {
+		t.Errorf("Unexpected station %q in %q", b.Station(), b.System())
+	}
+
+	if name, price := b.Sale(); name != "ImperialSlaves" || price != 15780 {
+		t.Errorf("Unexpected sale of %q for %d", name, price)
+	}
+}
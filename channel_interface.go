@@ -1,9 +1,14 @@
 package EDDNClient
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
-	zmq "github.com/pebbe/zmq4"
-	"log"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,34 +22,359 @@ const (
 // receiver is interested in.  These can be OR'd together to build any filter
 // the receiver wishes.
 const (
-	FilterNone        = 1 << iota // Filter nothing
-	FilterJournal     = 1 << iota // Filter journal messages
-	FilterShipyard    = 1 << iota // Filter shipyard messages
-	FilterCommodity   = 1 << iota // Filter commodity messages
-	FilterBlackmarket = 1 << iota // Filter blackmarket messages
-	FilterOutfitting  = 1 << iota // Filter outfitting messages.
+	FilterNone                = 1 << iota // Filter nothing
+	FilterJournal             = 1 << iota // Filter journal messages
+	FilterShipyard            = 1 << iota // Filter shipyard messages
+	FilterCommodity           = 1 << iota // Filter commodity messages
+	FilterBlackmarket         = 1 << iota // Filter blackmarket messages
+	FilterOutfitting          = 1 << iota // Filter outfitting messages.
+	FilterApproachSettlement  = 1 << iota // Filter approach settlement messages
+	FilterNavRoute            = 1 << iota // Filter nav route messages
+	FilterFSSSignalDiscovered = 1 << iota // Filter FSS signal discovered messages
+	FilterCodexEntry          = 1 << iota // Filter codex entry messages
+	FilterFSSBodySignals      = 1 << iota // Filter FSS body signals messages
+	FilterScanBaryCentre      = 1 << iota // Filter scan barycentre messages
+	FilterFCMaterials         = 1 << iota // Filter fleet carrier materials messages
 )
 
+// schemaFilters maps each schema name to the filter that disregards it.
+var schemaFilters = map[string]int{
+	"journal":             FilterJournal,
+	"shipyard":            FilterShipyard,
+	"commodity":           FilterCommodity,
+	"blackmarket":         FilterBlackmarket,
+	"outfitting":          FilterOutfitting,
+	"approachsettlement":  FilterApproachSettlement,
+	"navroute":            FilterNavRoute,
+	"fsssignaldiscovered": FilterFSSSignalDiscovered,
+	"codexentry":          FilterCodexEntry,
+	"fssbodysignals":      FilterFSSBodySignals,
+	"scanbarycentre":      FilterScanBaryCentre,
+	"fcmaterials_capi":    FilterFCMaterials,
+	"fcmaterials_journal": FilterFCMaterials,
+}
+
+// OverflowPolicy describes what a ChannelInterface does with a message when
+// the receiver isn't keeping up, and the channel it belongs on is full.
+type OverflowPolicy int
+
+// The overflow policies supported by a ChannelInterface.
+const (
+	OverflowBlock      OverflowPolicy = iota // Wait for the receiver.  (Default)
+	OverflowDropOldest                       // Drop the oldest buffered message
+	OverflowDropNewest                       // Drop the new message
+)
+
+// Default values used by a ChannelInterfaceConfig when a field is left unset.
+const (
+	DefaultReconnectMinDelay = 1 * time.Second
+	DefaultReconnectMaxDelay = 2 * time.Minute
+	DefaultReceiveTimeout    = 500 * time.Millisecond
+	DefaultIdleTimeout       = 5 * time.Minute
+	DefaultDrainTimeout      = 5 * time.Second
+)
+
+var (
+	errIdle         = errors.New("no messages received within the idle timeout")
+	errParseBacklog = errors.New("too many abandoned parses still running")
+)
+
+// maxAbandonedParses is the most parses given up on after the ParseTimeout
+// that may still be running in the background.  Frames are disregarded
+// rather than parsed while there are this many, so a flood of slow messages
+// can't grow without bound.
+const maxAbandonedParses = 64
+
+// ChannelInterfaceConfig contains the options used when creating a
+// ChannelInterface.  Any field left as its zero value will use the default.
+type ChannelInterfaceConfig struct {
+	// Address of the EDDN relay to subscribe to.  EDDNSubAddress by default.
+	// A "ws://", or "wss://" address connects to a websocket gateway
+	// relaying the same frames, for networks where ZeroMQ is blocked.
+	Address string
+
+	// Other relays to fail over to.  Each time the ChannelInterface
+	// reconnects it moves on to the next relay, returning to Address after
+	// the last.  Stats reports the relay currently in use.
+	FallbackAddresses []string
+
+	// SOCKS5 proxy used to reach the relay, either as "host:port" or
+	// "socks5://host:port".  Requires ZeroMQ 4.1 or later.  Websocket relays
+	// are always connected to directly.
+	SocksProxy string
+
+	// Local interface, or address the connection to a "tcp://" relay is
+	// made from.  i.e. "eth0", or "192.168.1.10".  Only an address is used
+	// for websocket relays.
+	SourceAddress string
+
+	// Only receive messages published with a topic beginning with this
+	// prefix, which ZeroMQ filters before they reach the ChannelInterface.
+	// EDDN publishes without a topic, so this is only useful with relays
+	// that publish each message under a topic such as its schema.  Every
+	// message is received by default.  Websocket relays aren't filtered.
+	// See SetSubscriptionPrefix.
+	SubscriptionPrefix string
+
+	// TLS configuration used for "wss://" relays, i.e. to trust a gateway's
+	// private CA.  The system roots are used by default.
+	TLSConfig *tls.Config
+
+	// Delay before the first attempt to reconnect to the relay after the
+	// connection is lost.  The delay doubles after each failed attempt.
+	ReconnectMinDelay time.Duration
+
+	// Maximum delay between attempts to reconnect to the relay.
+	ReconnectMaxDelay time.Duration
+
+	// How long to wait for a single message from the relay before giving up
+	// and trying again.  This also bounds how long Close() takes to stop the
+	// ChannelInterface goroutine.
+	ReceiveTimeout time.Duration
+
+	// If no messages are received within this window the connection is
+	// considered stale, and the ChannelInterface will reconnect.
+	IdleTimeout time.Duration
+
+	// Number of messages each channel can buffer before the OverflowPolicy
+	// is applied.  The channels are unbuffered by default.
+	BufferSize int
+
+	// What to do with messages when a channel is full.  While blocked the
+	// ChannelInterface stops reading from the relay which may cause EDDN to
+	// drop the connection, so a slow receiver may wish to drop messages
+	// instead.  OverflowBlock by default.
+	OverflowPolicy OverflowPolicy
+
+	// OnReconnect is called before each attempt to reconnect to the relay
+	// with the attempt number (starting at 1), the delay before the attempt
+	// is made, and the error that caused it.  It's called from the
+	// ChannelInterface goroutine so it must not block.
+	OnReconnect func(attempt int, delay time.Duration, err error)
+
+	// Number of goroutines parsing messages.  With a single worker messages
+	// are parsed as they're received, and delivered in the same order.  With
+	// more than one there is no ordering guarantee, even between messages on
+	// the same channel.  1 by default.
+	Workers int
+
+	// How long Close waits for messages already received to be delivered
+	// before discarding them.  DefaultDrainTimeout by default.
+	DrainTimeout time.Duration
+
+	// Maximum size of a message once it's decompressed.  Larger messages are
+	// disregarded.  DefaultMaxMessageSize by default.
+	MaxMessageSize int64
+
+	// How long a single message may take to parse before it's disregarded
+	// with a *ParseTimeoutError, and counted as a ParseError in Stats.  The
+	// parse can't be interrupted, so it carries on in the background until
+	// it finishes.  While 64 such parses are still running further messages
+	// are disregarded, and also counted as a ParseError.  Messages may take
+	// as long as they need by default.
+	ParseTimeout time.Duration
+
+	// Send messages using a schema that isn't supported on UnknownChan as a
+	// RawUnknown rather than disregarding them.
+	DeliverUnknown bool
+
+	// Deliver messages using the "/test" variant of a schema, which may be
+	// told apart with IsTestSchema.  They're disregarded by default.
+	IncludeTest bool
+
+	// Disregard messages that are missing fields their schema requires, or
+	// have them empty.  They're counted as Invalid in Stats.
+	Validate bool
+
+	// Disregard messages containing fields their Go type doesn't have.
+	// They're counted as ParseErrors in Stats.  See ParseOptions.Strict.
+	Strict bool
+
+	// Options used to decode journal events.  See ParseOptions.JournalDecode.
+	JournalDecode JournalDecodeOptions
+
+	// Disregard Scan events the filter returns false for before they're
+	// decoded.  They're counted as Filtered in Stats.  See
+	// ParseOptions.ScanFilter.
+	ScanFilter func(scan map[string]interface{}) bool
+
+	// Schemas restricts the ChannelInterface to messages using the named
+	// schemas, i.e. "commodity", or "journal", regardless of version.  Every
+	// schema is accepted when empty.
+	Schemas []string
+
+	// Metrics receives metrics for each message handled.  None are
+	// collected by default.
+	Metrics MetricsCollector
+
+	// Send every message received on the channel returned by RawFrames once
+	// it's decompressed, before any filtering.  This allows messages to be
+	// archived while also being decoded.
+	RawFrames bool
+
+	// Send a ConnEvent on the channel returned by Events whenever the
+	// connection to the relay is made, lost, or retried.
+	ConnEvents bool
+
+	// SchemaFilter, when set, is called with the $schemaRef of each message
+	// and only those it returns true for are decoded and delivered.
+	SchemaFilter func(schemaRef string) bool
+
+	// SampleEvery keeps only the first of every N messages using each of the
+	// named schemas, i.e. {"journal": 10}, and RateLimits keeps at most the
+	// given number of messages per second using each.  Both are applied
+	// before the message is decoded, after every other filter, and the
+	// messages disregarded are counted as Sampled in Stats.  Nothing is
+	// sampled by default.
+	SampleEvery map[string]int
+	RateLimits  map[string]float64
+
+	// Source, when set, provides the frames in place of a relay, and the
+	// options concerning the relay connection are ignored.  The
+	// ChannelInterface can only stop once Next returns, so a Source that
+	// may block for long should return an error now and then.
+	Source FrameSource
+}
+
+// withDefaults returns a copy of config with any unset fields set to their
+// defaults.
+func (config ChannelInterfaceConfig) withDefaults() ChannelInterfaceConfig {
+	if config.Address == "" {
+		config.Address = EDDNSubAddress
+	}
+
+	if config.ReconnectMinDelay <= 0 {
+		config.ReconnectMinDelay = DefaultReconnectMinDelay
+	}
+
+	if config.ReconnectMaxDelay <= 0 {
+		config.ReconnectMaxDelay = DefaultReconnectMaxDelay
+	}
+
+	if config.ReconnectMaxDelay < config.ReconnectMinDelay {
+		config.ReconnectMaxDelay = config.ReconnectMinDelay
+	}
+
+	if config.ReceiveTimeout <= 0 {
+		config.ReceiveTimeout = DefaultReceiveTimeout
+	}
+
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = DefaultIdleTimeout
+	}
+
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+
+	if config.DrainTimeout <= 0 {
+		config.DrainTimeout = DefaultDrainTimeout
+	}
+
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = DefaultMaxMessageSize
+	}
+
+	if config.BufferSize < 0 {
+		config.BufferSize = 0
+	}
+
+	return config
+}
+
 // A ChannelInterface provides an interface to a group of channels that
 // each provide various types of EDDN data separated into their respective
 // channels.  JournalChan, ShipyardChan, CommodityChan, BlackmarketChan,
 // and OutfittingChan each only send messages pertaining to their
-// respective types.  When a Done message is received all processing on the
-// receiver should halt as this means that the ChannelInterface is closed.
+// respective types.  When Done is closed all processing on the receiver
+// should halt as this means that the ChannelInterface is closed, and every
+// channel has been closed with it.
 //
 // It should be noted that the Journal channel can send several types that
 // must be asserted by the caller.  While this may be a bit tedious it
 // does provide type correctness, and allows the caller to know precisely
 // what data was provided by EDDN.
+//
+// Every channel that isn't filtered must be read by the receiver.  With the
+// default OverflowPolicy an unread channel will eventually stop the
+// ChannelInterface from delivering anything else.
+//
+// If the connection to the relay is lost the ChannelInterface will
+// reconnect automatically, backing off exponentially between attempts.
 type ChannelInterface struct {
-	Socket          *zmq.Socket        // Underlying ZeroMQ socket
-	JournalChan     <-chan Journal     // Channel for journal messages. (Provides many message types.)
-	ShipyardChan    <-chan Shipyard    // Channel for reading shipyard messages
-	CommodityChan   <-chan Commodity   // Channel for reading commodity messages
-	BlackmarketChan <-chan Blackmarket // Channel for reading blackmarket messages
-	OutfittingChan  <-chan Outfitting  // Channel for reading outfitting messages
-	ControlChan     chan<- int         // Channel providing goroutine control
-	Done            chan bool          // Sent when the ChannelInterface is done.
+	Socket                  *zmqSocket                 // Underlying ZeroMQ socket.  (Replaced when reconnecting, nil for websocket relays, Sources, and builds without ZeroMQ.)
+	JournalChan             <-chan Journal             // Channel for journal messages. (Provides many message types.)
+	ShipyardChan            <-chan Shipyard            // Channel for reading shipyard messages
+	CommodityChan           <-chan Commodity           // Channel for reading commodity messages
+	BlackmarketChan         <-chan Blackmarket         // Channel for reading blackmarket messages
+	OutfittingChan          <-chan Outfitting          // Channel for reading outfitting messages
+	ApproachSettlementChan  <-chan ApproachSettlement  // Channel for reading approach settlement messages
+	NavRouteChan            <-chan NavRoute            // Channel for reading nav route messages
+	FSSSignalDiscoveredChan <-chan FSSSignalDiscovered // Channel for reading FSS signal discovered messages
+	CodexEntryChan          <-chan CodexEntry          // Channel for reading codex entry messages
+	FSSBodySignalsChan      <-chan FSSBodySignals      // Channel for reading FSS body signals messages
+	ScanBaryCentreChan      <-chan ScanBaryCentre      // Channel for reading scan barycentre messages
+	FCMaterialsChan         <-chan FCMaterials         // Channel for reading fleet carrier materials messages
+	UnknownChan             <-chan RawUnknown          // Channel for unsupported schemas.  (Only with DeliverUnknown.)
+	ControlChan             chan<- int                 // Channel providing goroutine control
+	Done                    chan bool                  // Closed when the ChannelInterface is done.
+
+	cancel                  context.CancelFunc
+	closing                 chan struct{}
+	closeOnce               sync.Once
+	conn                    transport
+	config                  ChannelInterfaceConfig
+	filter                  int
+	schemas                 map[string]bool
+	parser                  *parser
+	journalChan             chan Journal
+	shipyardChan            chan Shipyard
+	commodityChan           chan Commodity
+	blackmarketChan         chan Blackmarket
+	outfittingChan          chan Outfitting
+	approachSettlementChan  chan ApproachSettlement
+	navRouteChan            chan NavRoute
+	fssSignalDiscoveredChan chan FSSSignalDiscovered
+	codexEntryChan          chan CodexEntry
+	fssBodySignalsChan      chan FSSBodySignals
+	scanBaryCentreChan      chan ScanBaryCentre
+	fcMaterialsChan         chan FCMaterials
+	unknownChan             chan RawUnknown
+	rawFrames               chan []byte
+	events                  chan ConnEvent
+	controlChan             chan int
+	lastReceived            time.Time
+	received                atomic.Uint64
+	parsed                  atomic.Uint64
+	parseErrors             atomic.Uint64
+	invalid                 atomic.Uint64
+	unsupported             atomic.Uint64
+	dropped                 atomic.Uint64
+	filtered                atomic.Uint64
+	sampled                 atomic.Uint64
+	duplicates              atomic.Uint64
+	reconnects              atomic.Uint64
+	oversized               atomic.Uint64
+	smallest                atomic.Uint64
+	largest                 atomic.Uint64
+	unhandledLock           sync.Mutex
+	unhandledEvents         map[string]uint64
+	relay                   atomic.Int32
+	state                   atomic.Int32
+	filterLock              sync.RWMutex
+	softwareFilter          *headerFilter
+	uploaderFilter          *headerFilter
+	dedup                   *dedupCache
+	sampler                 *sampler
+	parses                  chan struct{} // Semaphore of the parses running with a ParseTimeout
+	subscriptionPrefix      string
+	subscribed              string // Prefix the socket is subscribed to
+	handlerLock             sync.RWMutex
+	handlers                map[reflect.Type]func(interface{})
+	defaultHandler          func(interface{})
+	subscriptionLock        sync.RWMutex
+	subscriptions           map[*Subscription]bool
+	subscriptionsClosed     bool
 }
 
 // NewChannelInterface creates an active ChannelInterface using the provided
@@ -56,108 +386,820 @@ type ChannelInterface struct {
 // Should the receiver wish to begin receiving messages again then a new
 // ChannelInterface must be created.
 func NewChannelInterface(filter int) (channels *ChannelInterface, err error) {
+	return NewChannelInterfaceWithConfig(filter, ChannelInterfaceConfig{})
+}
+
+// NewChannelInterfaceWithConfig is the same as NewChannelInterface, but
+// allows the receiver to provide a ChannelInterfaceConfig.
+func NewChannelInterfaceWithConfig(filter int,
+	config ChannelInterfaceConfig) (channels *ChannelInterface, err error) {
+	return NewChannelInterfaceContext(context.Background(), filter, config)
+}
 
-	subscriber, err := zmq.NewSocket(zmq.SUB)
+// NewChannelInterfaceContext is the same as NewChannelInterfaceWithConfig,
+// but the ChannelInterface is closed when ctx is cancelled.  Cancelling ctx
+// is equivalent to calling Close().
+func NewChannelInterfaceContext(ctx context.Context, filter int,
+	config ChannelInterfaceConfig) (channels *ChannelInterface, err error) {
+
+	config = config.withDefaults()
+
+	conn, err := dial(config)
 
 	if err != nil {
 		return nil, err
 	}
 
-	subscriber.Connect(EDDNSubAddress)
-	subscriber.SetSubscribe("")
-	subscriber.SetConnectTimeout(time.Duration(600000))
-	subscriber.SetHeartbeatIvl(500 * time.Millisecond)
-	subscriber.SetTcpKeepalive(1)
+	ctx, cancel := context.WithCancel(ctx)
+
+	ci := newChannelInterface(filter, config)
+	ci.setConn(conn)
+	ci.cancel = cancel
+	ci.lastReceived = time.Now()
+	ci.emit(ConnEvent{Type: EventConnected, Relay: config.Address})
+
+	go ci.run(ctx, ci.receive)
+
+	return ci, nil
+}
 
-	journalChan := make(chan Journal)
-	shipyardChan := make(chan Shipyard)
-	commodityChan := make(chan Commodity)
-	blackmarketChan := make(chan Blackmarket)
-	outfittingChan := make(chan Outfitting)
+// newChannelInterface creates a ChannelInterface without a socket, or a
+// running goroutine.  config must already have its defaults set.
+func newChannelInterface(filter int, config ChannelInterfaceConfig) *ChannelInterface {
+	journalChan := make(chan Journal, config.BufferSize)
+	shipyardChan := make(chan Shipyard, config.BufferSize)
+	commodityChan := make(chan Commodity, config.BufferSize)
+	blackmarketChan := make(chan Blackmarket, config.BufferSize)
+	outfittingChan := make(chan Outfitting, config.BufferSize)
+	approachSettlementChan := make(chan ApproachSettlement, config.BufferSize)
+	navRouteChan := make(chan NavRoute, config.BufferSize)
+	fssSignalDiscoveredChan := make(chan FSSSignalDiscovered, config.BufferSize)
+	codexEntryChan := make(chan CodexEntry, config.BufferSize)
+	fssBodySignalsChan := make(chan FSSBodySignals, config.BufferSize)
+	scanBaryCentreChan := make(chan ScanBaryCentre, config.BufferSize)
+	fcMaterialsChan := make(chan FCMaterials, config.BufferSize)
+	unknownChan := make(chan RawUnknown, config.BufferSize)
 	controlChan := make(chan int, 1)
 	Done := make(chan bool)
 
-	go func() {
-		defer close(journalChan)
-		defer close(shipyardChan)
-		defer close(commodityChan)
-		defer close(blackmarketChan)
-		defer close(outfittingChan)
-		defer close(controlChan)
-		defer close(Done)
-
-		for {
-			// Check if we have any control messages first.
-			select {
-			case control := <-controlChan:
-				switch control {
-				case channelInterfaceClose:
-					Done <- true
-					return
-				}
-			default:
-				// NOOP
+	ci := &ChannelInterface{
+		JournalChan:             journalChan,
+		ShipyardChan:            shipyardChan,
+		CommodityChan:           commodityChan,
+		BlackmarketChan:         blackmarketChan,
+		OutfittingChan:          outfittingChan,
+		ApproachSettlementChan:  approachSettlementChan,
+		NavRouteChan:            navRouteChan,
+		FSSSignalDiscoveredChan: fssSignalDiscoveredChan,
+		CodexEntryChan:          codexEntryChan,
+		FSSBodySignalsChan:      fssBodySignalsChan,
+		ScanBaryCentreChan:      scanBaryCentreChan,
+		FCMaterialsChan:         fcMaterialsChan,
+		UnknownChan:             unknownChan,
+		ControlChan:             controlChan,
+		Done:                    Done,
+		closing:                 make(chan struct{}),
+		config:                  config,
+		subscriptionPrefix:      config.SubscriptionPrefix,
+		subscribed:              config.SubscriptionPrefix,
+		filter:                  filter,
+		journalChan:             journalChan,
+		shipyardChan:            shipyardChan,
+		commodityChan:           commodityChan,
+		blackmarketChan:         blackmarketChan,
+		outfittingChan:          outfittingChan,
+		approachSettlementChan:  approachSettlementChan,
+		navRouteChan:            navRouteChan,
+		fssSignalDiscoveredChan: fssSignalDiscoveredChan,
+		codexEntryChan:          codexEntryChan,
+		fssBodySignalsChan:      fssBodySignalsChan,
+		scanBaryCentreChan:      scanBaryCentreChan,
+		fcMaterialsChan:         fcMaterialsChan,
+		unknownChan:             unknownChan,
+		controlChan:             controlChan}
+
+	if len(config.Schemas) > 0 {
+		ci.schemas = make(map[string]bool, len(config.Schemas))
+
+		for _, name := range config.Schemas {
+			ci.schemas[name] = true
+		}
+	}
+
+	ci.sampler = newSampler(config)
+
+	if config.ParseTimeout > 0 {
+		ci.parses = make(chan struct{}, config.Workers+maxAbandonedParses)
+	}
+
+	if config.RawFrames {
+		ci.rawFrames = make(chan []byte, config.BufferSize)
+	}
+
+	if config.ConnEvents {
+		ci.events = make(chan ConnEvent, connEventBuffer)
+	}
+
+	ci.parser = &parser{
+		accept: ci.accept,
+		frame:  ci.observeSize,
+		options: ParseOptions{
+			DeliverUnknown: config.DeliverUnknown,
+			IncludeTest:    config.IncludeTest,
+			MaxMessageSize: config.MaxMessageSize,
+			Validate:       config.Validate,
+			Strict:         config.Strict,
+			JournalDecode:  config.JournalDecode,
+			ScanFilter:     config.ScanFilter,
+			Timing:         config.Metrics != nil}}
+
+	return ci
+}
+
+// endpoint returns the ZeroMQ endpoint of the relay in config, including the
+// source address if there is one.  i.e. "tcp://eth0;eddn.edcd.io:9500".
+func (config ChannelInterfaceConfig) endpoint() string {
+	if config.SourceAddress == "" || !strings.HasPrefix(config.Address, "tcp://") {
+		return config.Address
+	}
+
+	return "tcp://" + config.SourceAddress + ";" +
+		strings.TrimPrefix(config.Address, "tcp://")
+}
+
+// frameSource returns the next frame for the ChannelInterface goroutine.  ok
+// is false when nothing was received, and stop is true once there are no more
+// frames to receive.
+type frameSource func(ctx context.Context) (frame string, ok bool, stop bool)
+
+// run is the ChannelInterface goroutine.  It handles every frame from next
+// until the ChannelInterface is closed, ctx is cancelled, or next stops.
+func (ci *ChannelInterface) run(ctx context.Context, next frameSource) {
+	defer close(ci.journalChan)
+	defer close(ci.shipyardChan)
+	defer close(ci.commodityChan)
+	defer close(ci.blackmarketChan)
+	defer close(ci.outfittingChan)
+	defer close(ci.approachSettlementChan)
+	defer close(ci.navRouteChan)
+	defer close(ci.fssSignalDiscoveredChan)
+	defer close(ci.codexEntryChan)
+	defer close(ci.fssBodySignalsChan)
+	defer close(ci.scanBaryCentreChan)
+	defer close(ci.fcMaterialsChan)
+	defer close(ci.unknownChan)
+
+	if ci.rawFrames != nil {
+		defer close(ci.rawFrames)
+	}
+
+	if ci.events != nil {
+		defer close(ci.events)
+	}
+
+	defer close(ci.Done)
+	defer ci.closeSubscriptions()
+	defer ci.state.Store(int32(StateStopped))
+
+	var frames chan string
+	var wait func()
+
+	if ci.config.Workers > 1 {
+		frames = make(chan string, ci.config.Workers)
+		wait = ci.startWorkers(ctx, frames)
+	}
+
+	// The workers must finish before their channels are closed.
+	defer ci.drain(frames, wait)
+
+	for {
+		// Check if we have any control messages first.
+		select {
+		case <-ctx.Done():
+			ci.closeSocket()
+			return
+		case <-ci.closing:
+			ci.closeSocket()
+			return
+		case control := <-ci.controlChan:
+			if ci.handleControl(control) {
+				ci.closeSocket()
+				return
 			}
+		default:
+			// NOOP
+		}
+
+		eddnData, ok, stop := next(ctx)
+
+		if stop {
+			return
+		}
 
-			eddnData, err := subscriber.Recv(0)
+		if !ok {
+			continue
+		}
+
+		ci.received.Add(1)
+
+		if frames == nil {
+			ci.handleMessage(ctx, eddnData)
+			continue
+		}
+
+		select {
+		case frames <- eddnData:
+		case <-ctx.Done():
+		}
+	}
+}
 
-			if err != nil {
-				fmt.Printf("Error: %v", err)
-				log.Fatalln(err)
-				continue
+// drain waits for the workers to handle every frame already sent on frames,
+// and then cancels ci.  The workers are cancelled after the DrainTimeout so a
+// receiver that has stopped reading can't block the ChannelInterface forever.
+// frames is nil with a single worker, which has nothing left to drain.  Close
+// starts its own DrainTimeout, as the single worker is the goroutine that
+// would be blocked.
+func (ci *ChannelInterface) drain(frames chan string, wait func()) {
+	defer ci.cancel()
+
+	if frames == nil {
+		return
+	}
+
+	timeout := time.AfterFunc(ci.config.DrainTimeout, ci.cancel)
+	defer timeout.Stop()
+
+	close(frames)
+	wait()
+}
+
+// receive is the frameSource that reads from the relay, reconnecting when
+// the connection is lost, or has been idle for too long.
+func (ci *ChannelInterface) receive(ctx context.Context) (frame string, ok bool, stop bool) {
+	ci.updateSubscription()
+
+	eddnData, err := ci.conn.recv()
+
+	if errors.Is(err, errSourceDone) {
+		return "", false, true
+	}
+
+	if err != nil {
+		// Nothing was received within the receive timeout.  Unless we've
+		// been idle for too long just try again.
+		if errors.Is(err, errReceiveTimeout) {
+			if time.Since(ci.lastReceived) < ci.config.IdleTimeout {
+				return "", false, false
+			}
+
+			err = errIdle
+		}
+
+		logf("Error: %v", err)
+
+		if !ci.reconnect(ctx, err) {
+			return "", false, true
+		}
+
+		ci.lastReceived = time.Now()
+
+		return "", false, false
+	}
+
+	ci.lastReceived = time.Now()
+
+	return eddnData, true, false
+}
+
+// closeSocket closes the connection of ci to the relay if it has one.
+func (ci *ChannelInterface) closeSocket() {
+	if ci.conn != nil {
+		ci.conn.close()
+	}
+}
+
+// setConn makes conn the connection of ci to the relay.  Socket is only set
+// for ZeroMQ relays.
+func (ci *ChannelInterface) setConn(conn transport) {
+	ci.conn = conn
+	ci.Socket = socketOf(conn)
+}
+
+// startWorkers starts config.Workers goroutines handling each message sent on
+// frames.  It returns a function that waits for every worker to finish once
+// frames is closed.
+func (ci *ChannelInterface) startWorkers(ctx context.Context,
+	frames <-chan string) (wait func()) {
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < ci.config.Workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for eddnData := range frames {
+				ci.handleMessage(ctx, eddnData)
 			}
+		}()
+	}
+
+	return wg.Wait
+}
+
+// handleControl handles a single control message and reports whether the
+// goroutine should stop.
+func (ci *ChannelInterface) handleControl(control int) (stop bool) {
+	switch control {
+	case channelInterfaceClose:
+		return true
+	}
+
+	return false
+}
+
+// reconnect closes the current socket and dials the relay again, backing off
+// exponentially between failed attempts.  It reports false if the
+// ChannelInterface was closed while waiting to reconnect.
+func (ci *ChannelInterface) reconnect(ctx context.Context, cause error) (ok bool) {
+	ci.closeSocket()
+	ci.state.Store(int32(StateReconnecting))
+	ci.emit(ConnEvent{Type: EventDisconnected, Relay: ci.Stats().Relay, Err: cause})
+
+	delay := ci.config.ReconnectMinDelay
 
-			Message, err := parseJSON(eddnData)
+	for attempt := 1; ; attempt++ {
+		if ci.config.OnReconnect != nil {
+			ci.config.OnReconnect(attempt, delay, cause)
+		}
+
+		ci.emit(ConnEvent{Type: EventReconnecting, Attempt: attempt, Delay: delay})
 
-			if err != nil && err != errUnhandledSchema {
-				fmt.Printf("Error: %v", err)
-				continue
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ci.closing:
+			return false
+		case control := <-ci.controlChan:
+			if ci.handleControl(control) {
+				return false
 			}
+		case <-time.After(delay):
+			// NOOP
+		}
 
-			switch Message.(type) {
-			case Journal:
+		config := ci.config
+		config.Address = ci.nextRelay()
+		config.SubscriptionPrefix = ci.currentSubscriptionPrefix()
 
-				if filter&FilterJournal == 0 {
-					journalChan <- Message.(Journal)
-				}
+		conn, err := dial(config)
 
-			case Shipyard:
+		if err == nil {
+			ci.setConn(conn)
+			ci.subscribed = config.SubscriptionPrefix
+			ci.reconnects.Add(1)
+			ci.state.Store(int32(StateConnected))
+			ci.emit(ConnEvent{Type: EventConnected, Relay: config.Address,
+				Attempt: attempt})
+			return true
+		}
 
-				if filter&FilterShipyard == 0 {
-					shipyardChan <- Message.(Shipyard)
-				}
+		logf("Error: %v", err)
+		ci.emit(ConnEvent{Type: EventError, Relay: config.Address,
+			Attempt: attempt, Err: err})
+		cause = err
 
-			case Commodity:
+		delay *= 2
 
-				if filter&FilterCommodity == 0 {
-					commodityChan <- Message.(Commodity)
-				}
+		if delay > ci.config.ReconnectMaxDelay {
+			delay = ci.config.ReconnectMaxDelay
+		}
+	}
+}
+
+// relays returns every relay in config in the order they're tried.
+func (config ChannelInterfaceConfig) relays() []string {
+	return append([]string{config.Address}, config.FallbackAddresses...)
+}
 
-			case Blackmarket:
+// nextRelay moves ci on to the next relay and returns its address.
+func (ci *ChannelInterface) nextRelay() string {
+	relays := ci.config.relays()
+	relay := (int(ci.relay.Load()) + 1) % len(relays)
 
-				if filter&FilterBlackmarket == 0 {
-					blackmarketChan <- Message.(Blackmarket)
-				}
+	ci.relay.Store(int32(relay))
+
+	return relays[relay]
+}
 
-			case Outfitting:
+// accept reports whether the message with the given root passes the filter,
+// Schemas, SchemaFilter, header filters, and sampling of ci.  It's checked
+// before the message is decoded so messages the receiver isn't interested in
+// are disregarded cheaply.
+func (ci *ChannelInterface) accept(root Root) bool {
+	name, _, err := schemaKey(root.SchemaRef)
 
-				if filter&FilterOutfitting == 0 {
-					outfittingChan <- Message.(Outfitting)
-				}
+	// Let the parser report malformed schemas.
+	if err != nil {
+		return true
+	}
+
+	if ci.filter&schemaFilters[name] != 0 {
+		return false
+	}
+
+	if ci.schemas != nil && !ci.schemas[name] {
+		return false
+	}
+
+	if ci.config.SchemaFilter != nil && !ci.config.SchemaFilter(root.SchemaRef) {
+		return false
+	}
+
+	if !ci.acceptHeader(root.Header) {
+		ci.filtered.Add(1)
+		ci.metrics().ObserveDrop(DropFiltered)
+		return false
+	}
 
-			default:
-				// Probably an invalid, or test schema.  Silently disregard.
-				continue
+	if ci.duplicate(root) {
+		ci.duplicates.Add(1)
+		ci.metrics().ObserveDrop(DropDuplicate)
+		return false
+	}
+
+	if ci.sampler != nil && !ci.sampler.keep(name, time.Now()) {
+		ci.sampled.Add(1)
+		ci.metrics().ObserveDrop(DropSampled)
+		return false
+	}
+
+	return true
+}
+
+// ParseTimeoutError is returned for a message that took longer than the
+// ParseTimeout to parse.
+type ParseTimeoutError struct {
+	Timeout time.Duration // The ParseTimeout of the ChannelInterface
+}
+
+func (e *ParseTimeoutError) Error() string {
+	return fmt.Sprintf("message took longer than %v to parse", e.Timeout)
+}
+
+// parse parses eddnData with p, giving up after the ParseTimeout if there is
+// one.
+func (ci *ChannelInterface) parse(p *parser, eddnData string) (result ParseResult, err error) {
+	timeout := ci.config.ParseTimeout
+
+	if timeout <= 0 {
+		return p.parseJSON(eddnData)
+	}
+
+	type parsed struct {
+		result ParseResult
+		err    error
+	}
+
+	// An abandoned parse carries on in the background, so its hooks must not
+	// run once it has been given up on.  Otherwise it could send on
+	// RawFrames after the channel is closed, or be counted by the filters.
+	// Holding the lock while a hook runs means none are still running once
+	// the parse has been abandoned, so the hooks mustn't block.
+	var hookLock sync.Mutex
+	abandoned := false
+
+	guarded := *p
+
+	if p.accept != nil {
+		guarded.accept = func(root Root) bool {
+			hookLock.Lock()
+			defer hookLock.Unlock()
+
+			return !abandoned && p.accept(root)
+		}
+	}
+
+	if p.frame != nil {
+		guarded.frame = func(output []byte) {
+			hookLock.Lock()
+			defer hookLock.Unlock()
+
+			if !abandoned {
+				p.frame(output)
 			}
 		}
+	}
+
+	// Each parse holds a slot until it finishes, even once abandoned.  At
+	// most Workers parses aren't abandoned, so the rest are.
+	if ci.parses != nil {
+		select {
+		case ci.parses <- struct{}{}:
+		default:
+			return ParseResult{}, errParseBacklog
+		}
+	}
+
+	// Buffered so the goroutine can finish once the parse is abandoned.
+	done := make(chan parsed, 1)
+
+	go func() {
+		result, err := guarded.parseJSON(eddnData)
+		done <- parsed{result, err}
+
+		if ci.parses != nil {
+			<-ci.parses
+		}
 	}()
 
-	return &ChannelInterface{subscriber, journalChan, shipyardChan,
-		commodityChan, blackmarketChan,
-		outfittingChan, controlChan, Done}, nil
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case parsed := <-done:
+		return parsed.result, parsed.err
+	case <-timer.C:
+		hookLock.Lock()
+		abandoned = true
+		hookLock.Unlock()
+
+		return ParseResult{}, &ParseTimeoutError{timeout}
+	}
+}
+
+// handleMessage parses a single message from EDDN and sends it on the
+// appropriate channel.
+func (ci *ChannelInterface) handleMessage(ctx context.Context, eddnData string) {
+	p := ci.parser
+
+	// The raw frame is sent once the parse returns rather than from the
+	// frame hook, as sending may block, and a parse that times out may still
+	// be running when RawFrames is closed.
+	var frame []byte
+
+	if ci.rawFrames != nil {
+		framed := *p
+		framed.frame = func(output []byte) {
+			ci.observeSize(output)
+			frame = output
+		}
+		p = &framed
+	}
+
+	result, err := ci.parse(p, eddnData)
+
+	if frame != nil {
+		ci.send(ctx, ci.rawFrames, frame)
+	}
+
+	if errors.Is(err, errFiltered) {
+		return
+	}
+
+	var scanFiltered *ScanFilteredError
+
+	if errors.As(err, &scanFiltered) {
+		ci.filtered.Add(1)
+		ci.metrics().ObserveDrop(DropFiltered)
+		return
+	}
+
+	if ci.config.Metrics != nil {
+		schemaRef := result.SchemaRef
+
+		if err != nil {
+			schemaRef = errorSchemaRef(err)
+		}
+
+		ci.config.Metrics.ObserveParse(schemaRef, result.Duration, err)
+
+		if decode, ok := ci.config.Metrics.(DecodeCollector); ok && err == nil {
+			decode.ObserveDecode(schemaRef, result.DecodeDuration)
+		}
+	}
+
+	var unsupported *UnsupportedSchemaError
+
+	if errors.As(err, &unsupported) {
+		ci.unsupported.Add(1)
+		return
+	}
+
+	var invalid *ValidationError
+
+	if errors.As(err, &invalid) {
+		ci.invalid.Add(1)
+		return
+	}
+
+	var tooLarge *MessageTooLargeError
+
+	if errors.As(err, &tooLarge) {
+		ci.oversized.Add(1)
+	}
+
+	var unhandled *UnhandledEventError
+
+	if errors.As(err, &unhandled) {
+		ci.countUnhandledEvent(unhandled.Event)
+	}
+
+	if err != nil {
+		ci.parseErrors.Add(1)
+		logf("Error: %v", err)
+		return
+	}
+
+	if _, ok := result.Message.(RawUnknown); ok {
+		ci.unsupported.Add(1)
+	} else {
+		ci.parsed.Add(1)
+	}
+
+	filter := ci.filter
+	Message := result.Message
+
+	switch Message.(type) {
+	case Journal:
+
+		if filter&FilterJournal == 0 {
+			ci.deliver(ctx, ci.journalChan, Message.(Journal))
+		}
+
+	case Shipyard:
+
+		if filter&FilterShipyard == 0 {
+			ci.deliver(ctx, ci.shipyardChan, Message.(Shipyard))
+		}
+
+	case Shipyard1:
+
+		// ShipyardChan only carries the converted Shipyard, so the prices
+		// are given to a handler for Shipyard1.
+		if filter&FilterShipyard == 0 && !ci.dispatchHandled(Message) {
+			ci.deliver(ctx, ci.shipyardChan, Message.(Shipyard1).Shipyard())
+		}
+
+	case Commodity:
+
+		if filter&FilterCommodity == 0 {
+			ci.deliver(ctx, ci.commodityChan, Message.(Commodity))
+		}
+
+	case Blackmarket:
+
+		if filter&FilterBlackmarket == 0 {
+			ci.deliver(ctx, ci.blackmarketChan, Message.(Blackmarket))
+		}
+
+	case Outfitting:
+
+		if filter&FilterOutfitting == 0 {
+			ci.deliver(ctx, ci.outfittingChan, Message.(Outfitting))
+		}
+
+	case Outfitting1:
+
+		// OutfittingChan only carries the converted Outfitting, so the
+		// fields only version 1 has are given to a handler for Outfitting1.
+		if filter&FilterOutfitting == 0 && !ci.dispatchHandled(Message) {
+			ci.deliver(ctx, ci.outfittingChan, Message.(Outfitting1).Outfitting())
+		}
+
+	case ApproachSettlement:
+
+		if filter&FilterApproachSettlement == 0 {
+			ci.deliver(ctx, ci.approachSettlementChan, Message.(ApproachSettlement))
+		}
+
+	case NavRoute:
+
+		if filter&FilterNavRoute == 0 {
+			ci.deliver(ctx, ci.navRouteChan, Message.(NavRoute))
+		}
+
+	case FSSSignalDiscovered:
+
+		if filter&FilterFSSSignalDiscovered == 0 {
+			ci.deliver(ctx, ci.fssSignalDiscoveredChan, Message.(FSSSignalDiscovered))
+		}
+
+	case CodexEntry:
+
+		if filter&FilterCodexEntry == 0 {
+			ci.deliver(ctx, ci.codexEntryChan, Message.(CodexEntry))
+		}
+
+	case FSSBodySignals:
+
+		if filter&FilterFSSBodySignals == 0 {
+			ci.deliver(ctx, ci.fssBodySignalsChan, Message.(FSSBodySignals))
+		}
+
+	case ScanBaryCentre:
+
+		if filter&FilterScanBaryCentre == 0 {
+			ci.deliver(ctx, ci.scanBaryCentreChan, Message.(ScanBaryCentre))
+		}
+
+	case FCMaterials:
+
+		if filter&FilterFCMaterials == 0 {
+			ci.deliver(ctx, ci.fcMaterialsChan, Message.(FCMaterials))
+		}
+
+	case RawUnknown:
+		ci.deliver(ctx, ci.unknownChan, Message.(RawUnknown))
+
+	default:
+		// A schema registered by the receiver.  Without a handler there is
+		// no channel to send it on so it's silently disregarded.
+		ci.dispatch(Message)
+	}
+}
+
+// deliver sends msg on channel, which must be one of the ChannelInterface
+// channels, applying the OverflowPolicy if the channel is full.  Messages
+// with a handler are passed to it instead, and otherwise sent to the
+// subscriptions if there are any.
+func (ci *ChannelInterface) deliver(ctx context.Context, channel interface{},
+	msg interface{}) {
+
+	if ci.dispatch(msg) || ci.publish(ctx, msg) {
+		return
+	}
+
+	ci.send(ctx, channel, msg)
+}
+
+// send sends msg on channel applying the OverflowPolicy if the channel is
+// full.
+func (ci *ChannelInterface) send(ctx context.Context, channel interface{},
+	msg interface{}) {
+
+	ch := reflect.ValueOf(channel)
+	value := reflect.ValueOf(msg)
+
+	switch ci.config.OverflowPolicy {
+	case OverflowDropNewest:
+		if !ch.TrySend(value) {
+			ci.dropped.Add(1)
+			ci.metrics().ObserveDrop(DropOverflow)
+		}
+
+	case OverflowDropOldest:
+		for !ch.TrySend(value) {
+			ci.dropped.Add(1)
+			ci.metrics().ObserveDrop(DropOverflow)
+
+			// Unbuffered channels have nothing to drop but the new message.
+			if _, ok := ch.TryRecv(); !ok {
+				return
+			}
+		}
+
+	default:
+		reflect.Select([]reflect.SelectCase{
+			{Dir: reflect.SelectSend, Chan: ch, Send: value},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}})
+	}
+}
+
+// RawFrames returns the channel each decompressed message is sent on when
+// RawFrames is set in the ChannelInterfaceConfig, or nil otherwise.  Like the
+// other channels it must be read, or the OverflowPolicy applied.  The frames
+// must not be modified.
+func (ci *ChannelInterface) RawFrames() <-chan []byte {
+	return ci.rawFrames
 }
 
-// Close closes the given ChannelInterface ci.
+// Dropped returns the number of messages dropped due to the OverflowPolicy
+// since ci was created.
+func (ci *ChannelInterface) Dropped() uint64 {
+	return ci.dropped.Load()
+}
+
+// Close closes the given ChannelInterface ci.  No more frames are received
+// from the relay, but those already received are still parsed and delivered
+// before the channels are closed, and Done with them, so the receiver should
+// keep reading until Done is closed.  With a single worker they're delivered
+// in the order they were received.  If they aren't read within the
+// DrainTimeout they're discarded.  Cancelling the context given to
+// NewChannelInterfaceContext instead discards them immediately.
+//
+// Close doesn't wait for ci to be closed.  It may be called more than once,
+// and is safe to call after the context has been cancelled.
 func (ci *ChannelInterface) Close() {
-	ci.ControlChan <- channelInterfaceClose
+	ci.closeOnce.Do(func() {
+		close(ci.closing)
+
+		// A receiver that has stopped reading would otherwise block the
+		// delivery of the remaining messages forever.
+		if ci.cancel != nil {
+			time.AfterFunc(ci.config.DrainTimeout, ci.cancel)
+		}
+	})
 }
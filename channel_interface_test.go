@@ -0,0 +1,631 @@
+package EDDNClient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChannelInterfaceConfigDefaults(t *testing.T) {
+	config := ChannelInterfaceConfig{}.withDefaults()
+
+	if config.Address != EDDNSubAddress ||
+		config.ReconnectMinDelay != DefaultReconnectMinDelay ||
+		config.ReconnectMaxDelay != DefaultReconnectMaxDelay ||
+		config.ReceiveTimeout != DefaultReceiveTimeout ||
+		config.IdleTimeout != DefaultIdleTimeout ||
+		config.MaxMessageSize != DefaultMaxMessageSize ||
+		config.DrainTimeout != DefaultDrainTimeout ||
+		config.Workers != 1 {
+		t.Errorf("Unexpected defaults: %+v", config)
+	}
+
+	config = ChannelInterfaceConfig{
+		Address:           "tcp://localhost:9500",
+		ReconnectMinDelay: 5 * time.Minute,
+		ReconnectMaxDelay: time.Minute}.withDefaults()
+
+	if config.Address != "tcp://localhost:9500" ||
+		config.ReconnectMinDelay != 5*time.Minute ||
+		config.ReconnectMaxDelay != 5*time.Minute {
+		t.Errorf("Unexpected config: %+v", config)
+	}
+}
+
+func TestChannelInterfaceConfigEndpoint(t *testing.T) {
+	config := ChannelInterfaceConfig{}.withDefaults()
+
+	if config.endpoint() != EDDNSubAddress {
+		t.Errorf("Unexpected endpoint: %s", config.endpoint())
+	}
+
+	config.SourceAddress = "eth0"
+
+	if config.endpoint() != "tcp://eth0;eddn.edcd.io:9500" {
+		t.Errorf("Unexpected endpoint: %s", config.endpoint())
+	}
+
+	// Only tcp:// endpoints have a source address.
+	config.Address = "ipc:///tmp/eddn"
+
+	if config.endpoint() != "ipc:///tmp/eddn" {
+		t.Errorf("Unexpected endpoint: %s", config.endpoint())
+	}
+}
+
+func TestChannelInterfaceRelays(t *testing.T) {
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{
+		FallbackAddresses: []string{"tcp://a:9500", "tcp://b:9500"}}.withDefaults())
+
+	for _, expected := range []string{"tcp://a:9500", "tcp://b:9500", EDDNSubAddress} {
+		if relay := ci.nextRelay(); relay != expected {
+			t.Errorf("Expected %s, got %s", expected, relay)
+		}
+	}
+}
+
+// blockingSource returns a frameSource providing each of frames in turn, and
+// then nothing until the ChannelInterface is closed.
+func blockingSource(frames []string) frameSource {
+	return func(ctx context.Context) (frame string, ok bool, stop bool) {
+		if len(frames) == 0 {
+			time.Sleep(time.Millisecond)
+			return "", false, false
+		}
+
+		frame, frames = frames[0], frames[1:]
+
+		return frame, true, false
+	}
+}
+
+func TestChannelInterfaceCloseDrains(t *testing.T) {
+	frame := string(CompressFrame(commodity2Fixture))
+
+	ci := newChannelInterface(FilterNone,
+		ChannelInterfaceConfig{Workers: 2}.withDefaults())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ci.cancel = cancel
+
+	go ci.run(ctx, blockingSource([]string{frame, frame, frame, frame}))
+
+	// Nothing is read until every frame has been received, so they're
+	// still waiting in the workers when the ChannelInterface is closed.
+	deadline := time.Now().Add(5 * time.Second)
+
+	for ci.Stats().Received < 4 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Frames were not received")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	ci.Close()
+
+	var commodities int
+
+	for range ci.CommodityChan {
+		commodities++
+	}
+
+	if commodities != 4 {
+		t.Errorf("Expected 4 commodities delivered after Close, got %d", commodities)
+	}
+}
+
+func TestChannelInterfaceCloseDrainTimeout(t *testing.T) {
+	frame := string(CompressFrame(commodity2Fixture))
+
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{Workers: 2,
+		DrainTimeout: 10 * time.Millisecond}.withDefaults())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ci.cancel = cancel
+
+	go ci.run(ctx, blockingSource([]string{frame, frame}))
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for ci.Stats().Received < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Frames were not received")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	ci.Close()
+
+	// Nothing is read so the messages are discarded after the DrainTimeout.
+	select {
+	case <-ci.Done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("ChannelInterface was not closed after the DrainTimeout")
+	}
+}
+
+func TestChannelInterfaceCloseDrainTimeoutSingleWorker(t *testing.T) {
+	frame := string(CompressFrame(journalFixture(
+		`{"timestamp": "2021-05-25T18:06:08Z", "event": "Undocked"}`)))
+
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{Workers: 1,
+		DrainTimeout: 10 * time.Millisecond}.withDefaults())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ci.cancel = cancel
+
+	go ci.run(ctx, blockingSource([]string{frame, frame}))
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for ci.Stats().Received < 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Frames were not received")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	ci.Close()
+
+	// JournalChan is never read, so the worker is blocked delivering until
+	// the DrainTimeout.
+	select {
+	case <-ci.Done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("ChannelInterface was not closed after the DrainTimeout")
+	}
+}
+
+func TestDeliverDropNewest(t *testing.T) {
+	ci := &ChannelInterface{config: ChannelInterfaceConfig{
+		OverflowPolicy: OverflowDropNewest}}
+	ch := make(chan Commodity, 1)
+
+	ci.deliver(context.Background(), ch, Commodity{SchemaRef: "first"})
+	ci.deliver(context.Background(), ch, Commodity{SchemaRef: "second"})
+
+	if got := <-ch; got.SchemaRef != "first" {
+		t.Errorf("Expected the first message to be kept, got %s", got.SchemaRef)
+	}
+
+	if ci.Dropped() != 1 {
+		t.Errorf("Expected 1 dropped message, got %d", ci.Dropped())
+	}
+}
+
+func TestDeliverDropOldest(t *testing.T) {
+	ci := &ChannelInterface{config: ChannelInterfaceConfig{
+		OverflowPolicy: OverflowDropOldest}}
+	ch := make(chan Commodity, 1)
+
+	ci.deliver(context.Background(), ch, Commodity{SchemaRef: "first"})
+	ci.deliver(context.Background(), ch, Commodity{SchemaRef: "second"})
+
+	if got := <-ch; got.SchemaRef != "second" {
+		t.Errorf("Expected the second message to be kept, got %s", got.SchemaRef)
+	}
+
+	if ci.Dropped() != 1 {
+		t.Errorf("Expected 1 dropped message, got %d", ci.Dropped())
+	}
+
+	// Unbuffered channels can only drop the new message.
+	ci.deliver(context.Background(), make(chan Commodity),
+		Commodity{SchemaRef: "third"})
+
+	if ci.Dropped() != 2 {
+		t.Errorf("Expected 2 dropped messages, got %d", ci.Dropped())
+	}
+}
+
+func TestDeliverBlock(t *testing.T) {
+	ci := &ChannelInterface{}
+	ch := make(chan Commodity, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ci.deliver(ctx, ch, Commodity{SchemaRef: "first"})
+
+	done := make(chan bool)
+
+	go func() {
+		ci.deliver(ctx, ch, Commodity{SchemaRef: "second"})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatalf("Expected deliver to block while the channel is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected deliver to return after cancel")
+	}
+
+	if ci.Dropped() != 0 {
+		t.Errorf("Expected no dropped messages, got %d", ci.Dropped())
+	}
+}
+
+func TestChannelInterfaceAccept(t *testing.T) {
+	commodity := Root{SchemaRef: "https://eddn.edcd.io/schemas/commodity/3"}
+	journal := Root{SchemaRef: "https://eddn.edcd.io/schemas/journal/1"}
+
+	ci := &ChannelInterface{filter: FilterJournal}
+
+	if !ci.accept(commodity) || ci.accept(journal) {
+		t.Errorf("Expected filter to only disregard journal messages")
+	}
+
+	ci = &ChannelInterface{schemas: map[string]bool{"journal": true}}
+
+	if ci.accept(commodity) || !ci.accept(journal) {
+		t.Errorf("Expected Schemas to only accept journal messages")
+	}
+
+	ci = &ChannelInterface{config: ChannelInterfaceConfig{
+		SchemaFilter: func(schemaRef string) bool {
+			return strings.HasSuffix(schemaRef, "/commodity/3")
+		}}}
+
+	if !ci.accept(commodity) || ci.accept(journal) {
+		t.Errorf("Expected SchemaFilter to only accept commodity messages")
+	}
+
+	// Malformed schemas are left for the parser to report.
+	if !ci.accept(Root{SchemaRef: "nonsense"}) {
+		t.Errorf("Expected malformed schema to be accepted")
+	}
+}
+
+func TestChannelInterfaceWorkers(t *testing.T) {
+	ci := newChannelInterface(FilterNone,
+		ChannelInterfaceConfig{Workers: 4}.withDefaults())
+	frames := make(chan string)
+	wait := ci.startWorkers(context.Background(), frames)
+	data := compress(t, commodity2Fixture)
+
+	go func() {
+		for i := 0; i < 20; i++ {
+			frames <- data
+		}
+
+		close(frames)
+	}()
+
+	for i := 0; i < 20; i++ {
+		select {
+		case msg := <-ci.CommodityChan:
+			if msg.Message.StationName != "Azeban Orbital" {
+				t.Fatalf("Unexpected message: %+v", msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected 20 messages, got %d", i)
+		}
+	}
+
+	wait()
+}
+
+func BenchmarkChannelInterfaceWorkers(b *testing.B) {
+	data := compress(b, commodity2Fixture)
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			ci := newChannelInterface(FilterNone,
+				ChannelInterfaceConfig{Workers: workers}.withDefaults())
+			frames := make(chan string, workers)
+			wait := ci.startWorkers(context.Background(), frames)
+
+			go func() {
+				for i := 0; i < b.N; i++ {
+					frames <- data
+				}
+
+				close(frames)
+			}()
+
+			for i := 0; i < b.N; i++ {
+				<-ci.CommodityChan
+			}
+
+			wait()
+		})
+	}
+}
+
+func TestChannelInterfaceDeliverUnknown(t *testing.T) {
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{
+		BufferSize:     1,
+		DeliverUnknown: true}.withDefaults())
+	fixture := strings.Replace(commodity2Fixture, "commodity/2", "commodity/4", 1)
+
+	ci.handleMessage(context.Background(), compress(t, fixture))
+
+	select {
+	case unknown := <-ci.UnknownChan:
+		if !strings.HasSuffix(unknown.SchemaRef, "/commodity/4") {
+			t.Errorf("Unexpected unknown message: %+v", unknown)
+		}
+	default:
+		t.Fatalf("Expected a message on UnknownChan")
+	}
+}
+
+func TestChannelInterfaceStats(t *testing.T) {
+	ci := newChannelInterface(FilterNone,
+		ChannelInterfaceConfig{BufferSize: 1, Validate: true}.withDefaults())
+	unsupported := strings.Replace(commodity2Fixture, "commodity/2", "commodity/4", 1)
+	invalid := strings.Replace(commodity2Fixture, `"Azeban Orbital"`, `""`, 1)
+
+	ci.SetSoftwareFilter(nil, []string{"Broken Uploader"})
+
+	ci.handleMessage(context.Background(), compress(t, commodity2Fixture))
+	ci.handleMessage(context.Background(), compress(t, unsupported))
+	ci.handleMessage(context.Background(), "\x78\x9cthis is not deflate data")
+	ci.handleMessage(context.Background(), compress(t, invalid))
+	ci.handleMessage(context.Background(), compress(t, strings.Replace(
+		commodity2Fixture, "My Awesome Market Uploader", "Broken Uploader", 1)))
+
+	stats := ci.Stats()
+
+	if stats.Parsed != 1 || stats.Unsupported != 1 || stats.ParseErrors != 1 ||
+		stats.Invalid != 1 || stats.Filtered != 1 || stats.Dropped != 0 ||
+		stats.Reconnects != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	if stats.State != StateConnected {
+		t.Errorf("Expected connected state, got %v", stats.State)
+	}
+}
+
+func TestChannelInterfaceStatsMessageSize(t *testing.T) {
+	padded := strings.Replace(commodity2Fixture, `"commodities": [`, `"commodities": [ `, 1)
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{
+		BufferSize:     2,
+		MaxMessageSize: int64(len(padded))}.withDefaults())
+
+	ci.handleMessage(context.Background(), compress(t, commodity2Fixture))
+	ci.handleMessage(context.Background(), compress(t, padded))
+	ci.handleMessage(context.Background(), compress(t, padded+strings.Repeat(" ", 64)))
+
+	stats := ci.Stats()
+
+	if stats.Oversized != 1 || stats.ParseErrors != 1 || stats.Parsed != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	if stats.Smallest != uint64(len(commodity2Fixture)) ||
+		stats.Largest != uint64(len(padded)) {
+		t.Errorf("Unexpected sizes %d, and %d", stats.Smallest, stats.Largest)
+	}
+}
+
+func TestChannelInterfaceParseTimeout(t *testing.T) {
+	RegisterJournalEvent("SlowParseTest", func(event map[string]interface{}) (interface{}, error) {
+		time.Sleep(200 * time.Millisecond)
+		return event, nil
+	})
+
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{
+		BufferSize:   1,
+		ParseTimeout: 10 * time.Millisecond}.withDefaults())
+
+	ci.handleMessage(context.Background(), compress(t, journalFixture(
+		`{"timestamp": "2021-05-25T18:06:08Z", "event": "SlowParseTest"}`)))
+	ci.handleMessage(context.Background(), compress(t, commodity2Fixture))
+
+	if stats := ci.Stats(); stats.ParseErrors != 1 || stats.Parsed != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	select {
+	case msg := <-ci.JournalChan:
+		t.Errorf("Unexpected journal delivered: %+v", msg)
+	default:
+	}
+}
+
+func TestChannelInterfaceParseBacklog(t *testing.T) {
+	release := make(chan bool)
+
+	RegisterJournalEvent("BlockedParseTest", func(event map[string]interface{}) (interface{}, error) {
+		<-release
+		return event, nil
+	})
+
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{
+		ParseTimeout: time.Millisecond}.withDefaults())
+	frame := compress(t, journalFixture(
+		`{"timestamp": "2021-05-25T18:06:08Z", "event": "BlockedParseTest"}`))
+
+	// Every slot is taken by a parse that has been abandoned, but is still
+	// running.
+	for i := 0; i < cap(ci.parses); i++ {
+		var timedOut *ParseTimeoutError
+
+		if _, err := ci.parse(ci.parser, frame); !errors.As(err, &timedOut) {
+			t.Fatalf("Expected ParseTimeoutError, got %v", err)
+		}
+	}
+
+	if _, err := ci.parse(ci.parser, frame); err != errParseBacklog {
+		t.Errorf("Expected errParseBacklog, got %v", err)
+	}
+
+	// The slots are freed once the abandoned parses finish.
+	close(release)
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for len(ci.parses) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Abandoned parses did not finish")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := ci.parse(ci.parser, compress(t, commodity2Fixture)); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestChannelInterfaceParseTimeoutRawFrames(t *testing.T) {
+	finished := make(chan bool)
+
+	RegisterJournalEvent("SlowRawFramesTest", func(event map[string]interface{}) (interface{}, error) {
+		time.Sleep(100 * time.Millisecond)
+		close(finished)
+		return event, nil
+	})
+
+	frames := [][]byte{CompressFrame(journalFixture(
+		`{"timestamp": "2021-05-25T18:06:08Z", "event": "SlowRawFramesTest"}`))}
+
+	ci := NewMockChannelInterface(context.Background(), FilterNone,
+		ChannelInterfaceConfig{
+			BufferSize:   1,
+			RawFrames:    true,
+			ParseTimeout: time.Millisecond}, frames)
+
+	// Close once the parse has been abandoned, but before it finishes.
+	for ci.Stats().ParseErrors == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ci.Close()
+
+	select {
+	case <-ci.Done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("ChannelInterface was not closed")
+	}
+
+	// The abandoned parse finishes after the channels are closed, and must
+	// neither send its raw frame, nor be counted.
+	<-finished
+	time.Sleep(10 * time.Millisecond)
+
+	for frame := range ci.RawFrames() {
+		t.Errorf("Unexpected raw frame from an abandoned parse: %s", frame)
+	}
+
+	if stats := ci.Stats(); stats.ParseErrors != 1 || stats.Largest != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestChannelInterfaceUnhandledEvents(t *testing.T) {
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{}.withDefaults())
+	event := func(name string) string {
+		return compress(t, journalFixture(
+			`{"timestamp": "2021-05-25T18:06:08Z", "event": "`+name+`"}`))
+	}
+
+	ci.handleMessage(context.Background(), event("Music"))
+	ci.handleMessage(context.Background(), event("Music"))
+	ci.handleMessage(context.Background(), event("ReceiveText"))
+
+	events := ci.UnhandledEvents()
+
+	if len(events) != 2 || events["Music"] != 2 || events["ReceiveText"] != 1 {
+		t.Errorf("Unexpected unhandled events: %v", events)
+	}
+
+	// The names are bounded, but those already seen are still counted.
+	for i := 0; i < maxUnhandledEvents; i++ {
+		ci.countUnhandledEvent(fmt.Sprintf("Event%d", i))
+	}
+
+	ci.handleMessage(context.Background(), event("Music"))
+
+	if events = ci.UnhandledEvents(); len(events) != maxUnhandledEvents ||
+		events["Music"] != 3 {
+		t.Errorf("Unexpected unhandled events: %d names, Music %d", len(events),
+			events["Music"])
+	}
+}
+
+func TestChannelInterfaceSubscriptionPrefix(t *testing.T) {
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{
+		SubscriptionPrefix: "commodity"}.withDefaults())
+
+	if ci.subscribed != "commodity" {
+		t.Errorf("Expected to start subscribed to commodity, got %q", ci.subscribed)
+	}
+
+	ci.SetSubscriptionPrefix("journal")
+
+	// The subscription only changes once the goroutine applies it.
+	if ci.subscribed != "commodity" {
+		t.Errorf("Subscription changed before it was applied: %q", ci.subscribed)
+	}
+
+	ci.updateSubscription()
+
+	if ci.subscribed != "journal" {
+		t.Errorf("Expected to be subscribed to journal, got %q", ci.subscribed)
+	}
+}
+
+func TestChannelInterfaceOutfitting1(t *testing.T) {
+	ci := newChannelInterface(FilterNone,
+		ChannelInterfaceConfig{BufferSize: 1}.withDefaults())
+
+	// Without a handler the converted Outfitting is sent on OutfittingChan.
+	ci.handleMessage(context.Background(), compress(t, outfitting1Fixture))
+
+	if msg := <-ci.OutfittingChan; len(msg.Message.Modules) != 2 {
+		t.Errorf("Unexpected outfitting: %+v", msg)
+	}
+
+	var handled Outfitting1
+
+	ci.On(reflect.TypeOf(Outfitting1{}), func(msg interface{}) {
+		handled = msg.(Outfitting1)
+	})
+
+	ci.handleMessage(context.Background(), compress(t, outfitting1Fixture))
+
+	if len(handled.Message.Modules) != 2 ||
+		handled.Message.Modules[0].Category != "weapon" {
+		t.Errorf("Unexpected handled outfitting: %+v", handled)
+	}
+}
+
+func TestChannelInterfaceShipyard1(t *testing.T) {
+	ci := newChannelInterface(FilterNone,
+		ChannelInterfaceConfig{BufferSize: 1}.withDefaults())
+
+	// Without a handler the converted Shipyard is sent on ShipyardChan.
+	ci.handleMessage(context.Background(), compress(t, shipyard1Fixture))
+
+	if msg := <-ci.ShipyardChan; len(msg.Message.Ships) != 2 {
+		t.Errorf("Unexpected shipyard: %+v", msg)
+	}
+
+	var handled Shipyard1
+
+	ci.On(reflect.TypeOf(Shipyard1{}), func(msg interface{}) {
+		handled = msg.(Shipyard1)
+	})
+
+	ci.handleMessage(context.Background(), compress(t, shipyard1Fixture))
+
+	if len(handled.Message.Ships) != 2 || handled.Message.Ships[0].Price != 32000 {
+		t.Errorf("Unexpected handled shipyard: %+v", handled)
+	}
+}
@@ -0,0 +1,143 @@
+//go:build cgo && !nozmq
+
+package EDDNClient
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestChannelInterfaceIdleReconnect(t *testing.T) {
+	reconnected := make(chan error, 1)
+
+	// Nothing is listening here so the connection will go idle.
+	ci, err := NewChannelInterfaceWithConfig(FilterNone,
+		ChannelInterfaceConfig{
+			Address:        "tcp://127.0.0.1:59500",
+			ReceiveTimeout: 10 * time.Millisecond,
+			IdleTimeout:    50 * time.Millisecond,
+			OnReconnect: func(attempt int, delay time.Duration, err error) {
+				select {
+				case reconnected <- err:
+				default:
+				}
+			}})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	defer ci.Close()
+
+	select {
+	case err := <-reconnected:
+		if err != errIdle {
+			t.Errorf("Expected idle error, got %v", err)
+		}
+
+		// The first attempt waits ReconnectMinDelay before dialing.
+		if state := ci.Stats().State; state != StateReconnecting {
+			t.Errorf("Expected reconnecting state, got %v", state)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("ChannelInterface did not reconnect when idle")
+	}
+}
+
+func TestChannelInterfaceFailover(t *testing.T) {
+	reconnected := make(chan bool, 1)
+
+	// Nothing is listening on either relay so each will go idle in turn.
+	ci, err := NewChannelInterfaceWithConfig(FilterNone,
+		ChannelInterfaceConfig{
+			Address:           "tcp://127.0.0.1:59500",
+			FallbackAddresses: []string{"tcp://127.0.0.1:59501"},
+			ReconnectMinDelay: time.Millisecond,
+			ReceiveTimeout:    10 * time.Millisecond,
+			IdleTimeout:       50 * time.Millisecond,
+			OnReconnect: func(attempt int, delay time.Duration, err error) {
+				select {
+				case reconnected <- true:
+				default:
+				}
+			}})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	defer ci.Close()
+
+	if relay := ci.Stats().Relay; relay != "tcp://127.0.0.1:59500" {
+		t.Errorf("Expected primary relay, got %s", relay)
+	}
+
+	deadline := time.After(5 * time.Second)
+
+	for ci.Stats().Relay != "tcp://127.0.0.1:59501" {
+		select {
+		case <-reconnected:
+		case <-deadline:
+			t.Fatalf("ChannelInterface did not fail over to the fallback relay")
+		}
+	}
+}
+
+func TestChannelInterfaceContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Nothing is listening here so nothing will ever be received.
+	ci, err := NewChannelInterfaceContext(ctx, FilterNone,
+		ChannelInterfaceConfig{Address: "tcp://127.0.0.1:59500"})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	cancel()
+
+	select {
+	case <-ci.Done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("ChannelInterface was not closed after cancel")
+	}
+
+	if _, ok := <-ci.JournalChan; ok {
+		t.Errorf("Expected JournalChan to be closed")
+	}
+
+	if _, ok := <-ci.CommodityChan; ok {
+		t.Errorf("Expected CommodityChan to be closed")
+	}
+
+	// Closing after the context is cancelled must not block, or panic.
+	ci.Close()
+}
+
+func TestChannelInterfaceClose(t *testing.T) {
+	ci, err := NewChannelInterfaceWithConfig(FilterNone,
+		ChannelInterfaceConfig{Address: "tcp://127.0.0.1:59500"})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ci.Close()
+
+	select {
+	case <-ci.Done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("ChannelInterface was not closed")
+	}
+
+	if _, ok := <-ci.ShipyardChan; ok {
+		t.Errorf("Expected ShipyardChan to be closed")
+	}
+
+	if state := ci.Stats().State; state != StateStopped {
+		t.Errorf("Expected stopped state, got %v", state)
+	}
+
+	ci.Close()
+}
@@ -0,0 +1,38 @@
+package EDDNClient
+
+// CodexEntryMessage contains a codex discovery sent to EDDN.  Name,
+// Category, SubCategory, and Region are the game's internal names, i.e.
+// "$Codex_Ent_Stratum_07_F_Name;", and should be used when matching entries.
+// The localised variants are normally removed by the uploader, so they're
+// only provided for the rare message that still has them.  The body, and
+// coordinate fields are only present for discoveries made on a body.
+type CodexEntryMessage struct {
+	BodyID               int      `json:"BodyID,omitempty"`                // Optional
+	BodyName             string   `json:"BodyName,omitempty"`              // Optional
+	Category             string   `json:"Category"`                        // Required
+	CategoryLocalised    string   `json:"Category_Localised,omitempty"`    // Optional
+	EntryID              int64    `json:"EntryID"`                         // Required
+	Event                string   `json:"event"`                           // Required
+	Latitude             *float64 `json:"Latitude,omitempty"`              // Optional
+	Longitude            *float64 `json:"Longitude,omitempty"`             // Optional
+	Name                 string   `json:"Name"`                            // Required
+	NameLocalised        string   `json:"Name_Localised,omitempty"`        // Optional
+	NearestDestination   string   `json:"NearestDestination,omitempty"`    // Optional
+	Region               string   `json:"Region"`                          // Required
+	RegionLocalised      string   `json:"Region_Localised,omitempty"`      // Optional
+	StarPos              StarPos  `json:"StarPos"`                         // Required
+	SubCategory          string   `json:"SubCategory"`                     // Required
+	SubCategoryLocalised string   `json:"SubCategory_Localised,omitempty"` // Optional
+	System               string   `json:"System"`                          // Required
+	SystemAddress        int64    `json:"SystemAddress"`                   // Required
+	Timestamp            string   `json:"timestamp"`                       // Required
+	Traits               []string `json:"Traits,omitempty"`                // Optional
+	VoucherAmount        int64    `json:"VoucherAmount,omitempty"`         // Optional
+}
+
+// CodexEntry is the high level type that contains the entire JSON message.
+type CodexEntry struct {
+	SchemaRef string            `json:"$schemaRef"`
+	Header    Header            `json:"header"`
+	Message   CodexEntryMessage `json:"message"`
+}
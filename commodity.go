@@ -1,5 +1,9 @@
 package EDDNClient
 
+import (
+	"strings"
+)
+
 // Commodities describes various commodities sent in a Message.
 type Commodities struct {
 	BuyPrice      int      `json:"buyPrice"`
@@ -13,12 +17,24 @@ type Commodities struct {
 	StockBracket  int      `json:"stockBracket"`
 }
 
-// CommodityMessage contains the commodity data sent to EDDN.
+// Economy describes one of the economies of a station, and its proportion of
+// the station's market.
+type Economy struct {
+	Name       string  `json:"name"`
+	Proportion float64 `json:"proportion"`
+}
+
+// CommodityMessage contains the commodity data sent to EDDN.  Economies,
+// Prohibited, and StationType are only sent by newer clients.  Prohibited
+// lists the names of the commodities that are illegal at the station.
 type CommodityMessage struct {
-	Commodities []Commodities `json:"commodities"` // Required
-	StationName string        `json:"stationName"` // Required
-	SystemName  string        `json:"systemName"`  // Required
-	Timestamp   string        `json:"timestamp"`   // Required
+	Commodities []Commodities `json:"commodities"`           // Required
+	Economies   []Economy     `json:"economies,omitempty"`   // Optional
+	Prohibited  []string      `json:"prohibited,omitempty"`  // Optional
+	StationName string        `json:"stationName"`           // Required
+	StationType string        `json:"stationType,omitempty"` // Optional
+	SystemName  string        `json:"systemName"`            // Required
+	Timestamp   string        `json:"timestamp"`             // Required
 }
 
 // Commodity is the high level type that contains the entire JSON message.
@@ -27,3 +43,163 @@ type Commodity struct {
 	Header    Header           `json:"header"`
 	Message   CommodityMessage `json:"message"`
 }
+
+// StationType returns the StationType of the station the market belongs to,
+// or StationTypeUnknown if it wasn't sent.
+func (c Commodity) StationType() StationType {
+	return ParseStationType(c.Message.StationType)
+}
+
+// Commodity1Message contains the commodity data sent to EDDN using the
+// version 1 schema.  Each message only describes a single commodity.
+type Commodity1Message struct {
+	BuyPrice     int    `json:"buyPrice"`
+	Demand       int    `json:"demand"`
+	DemandLevel  string `json:"demandLevel,omitempty"`
+	ItemName     string `json:"itemName"`    // Required
+	SellPrice    int    `json:"sellPrice"`   // Required
+	StationName  string `json:"stationName"` // Required
+	StationStock int    `json:"stationStock"`
+	SupplyLevel  string `json:"supplyLevel,omitempty"`
+	SystemName   string `json:"systemName"` // Required
+	Timestamp    string `json:"timestamp"`  // Required
+}
+
+// Commodity1 is the high level type that contains the entire version 1 JSON
+// message.
+type Commodity1 struct {
+	SchemaRef string            `json:"$schemaRef"`
+	Header    Header            `json:"header"`
+	Message   Commodity1Message `json:"message"`
+}
+
+// Commodities2 describes various commodities sent in a version 2 Message.
+type Commodities2 struct {
+	BuyPrice    int    `json:"buyPrice"`
+	Demand      int    `json:"demand"`
+	DemandLevel string `json:"demandLevel,omitempty"`
+	Name        string `json:"name"`
+	SellPrice   int    `json:"sellPrice"`
+	Supply      int    `json:"supply"`
+	SupplyLevel string `json:"supplyLevel,omitempty"`
+}
+
+// Commodity2Message contains the commodity data sent to EDDN using the
+// version 2 schema.
+type Commodity2Message struct {
+	Commodities []Commodities2 `json:"commodities"` // Required
+	StationName string         `json:"stationName"` // Required
+	SystemName  string         `json:"systemName"`  // Required
+	Timestamp   string         `json:"timestamp"`   // Required
+}
+
+// Commodity2 is the high level type that contains the entire version 2 JSON
+// message.
+type Commodity2 struct {
+	SchemaRef string            `json:"$schemaRef"`
+	Header    Header            `json:"header"`
+	Message   Commodity2Message `json:"message"`
+}
+
+// levelToBracket converts the "Low", "Med", and "High" levels used by the
+// older schemas into the brackets used by version 3.
+func levelToBracket(level string) int {
+	switch level {
+	case "Low":
+		return 1
+	case "Med":
+		return 2
+	case "High":
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Commodity converts a version 1 message into the current Commodity type.
+// The schema reference is left untouched so the receiver can still tell
+// which version the data originally came from.
+func (c Commodity1) Commodity() Commodity {
+	msg := c.Message
+
+	return Commodity{c.SchemaRef, c.Header, CommodityMessage{
+		Commodities: []Commodities{{
+			BuyPrice:      msg.BuyPrice,
+			Demand:        msg.Demand,
+			DemandBracket: levelToBracket(msg.DemandLevel),
+			Name:          msg.ItemName,
+			SellPrice:     msg.SellPrice,
+			Stock:         msg.StationStock,
+			StockBracket:  levelToBracket(msg.SupplyLevel)}},
+		StationName: msg.StationName,
+		SystemName:  msg.SystemName,
+		Timestamp:   msg.Timestamp}}
+}
+
+// Commodity converts a version 2 message into the current Commodity type.
+// The schema reference is left untouched so the receiver can still tell
+// which version the data originally came from.
+func (c Commodity2) Commodity() Commodity {
+	commodities := make([]Commodities, 0, len(c.Message.Commodities))
+
+	for _, item := range c.Message.Commodities {
+		commodities = append(commodities, Commodities{
+			BuyPrice:      item.BuyPrice,
+			Demand:        item.Demand,
+			DemandBracket: levelToBracket(item.DemandLevel),
+			Name:          item.Name,
+			SellPrice:     item.SellPrice,
+			Stock:         item.Supply,
+			StockBracket:  levelToBracket(item.SupplyLevel)})
+	}
+
+	return Commodity{c.SchemaRef, c.Header, CommodityMessage{
+		Commodities: commodities,
+		StationName: c.Message.StationName,
+		SystemName:  c.Message.SystemName,
+		Timestamp:   c.Message.Timestamp}}
+}
+
+// Find returns the commodity with the given name, ignoring case.  ok is
+// false if the station doesn't list it.
+func (c Commodity) Find(name string) (commodity Commodities, ok bool) {
+	for _, item := range c.Message.Commodities {
+		if strings.EqualFold(item.Name, name) {
+			return item, true
+		}
+	}
+
+	return commodity, false
+}
+
+// CheapestBuy returns the commodity with the lowest buy price that the
+// station has in stock.  ok is false if the station sells nothing.
+func (c Commodity) CheapestBuy() (commodity Commodities, ok bool) {
+	for _, item := range c.Message.Commodities {
+		if item.BuyPrice <= 0 || item.Stock <= 0 {
+			continue
+		}
+
+		if !ok || item.BuyPrice < commodity.BuyPrice {
+			commodity, ok = item, true
+		}
+	}
+
+	return commodity, ok
+}
+
+// BestSell returns the commodity with the highest sell price at the station.
+// ok is false if the station buys nothing.
+func (c Commodity) BestSell() (commodity Commodities, ok bool) {
+	for _, item := range c.Message.Commodities {
+		if item.SellPrice <= 0 {
+			continue
+		}
+
+		if !ok || item.SellPrice > commodity.SellPrice {
+			commodity, ok = item, true
+		}
+	}
+
+	return commodity, ok
+}
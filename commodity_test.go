@@ -0,0 +1,155 @@
+package EDDNClient
+
+import (
+	"testing"
+)
+
+func TestCommodityHelpers(t *testing.T) {
+	c := Commodity{Message: CommodityMessage{Commodities: []Commodities{
+		{Name: "gold", BuyPrice: 9000, SellPrice: 8800, Stock: 12},
+		{Name: "tea", BuyPrice: 1200, SellPrice: 1100, Stock: 0},
+		{Name: "water", BuyPrice: 0, SellPrice: 300, Demand: 40},
+		{Name: "beer", BuyPrice: 150, SellPrice: 120, Stock: 800},
+		{Name: "painite", SellPrice: 95000, Demand: 3},
+	}}}
+
+	if item, ok := c.Find("Gold"); !ok || item.Name != "gold" {
+		t.Errorf("Find(Gold) = %+v, %v", item, ok)
+	}
+
+	if _, ok := c.Find("tritium"); ok {
+		t.Error("Find(tritium) found a commodity the station doesn't list")
+	}
+
+	if item, ok := c.CheapestBuy(); !ok || item.Name != "beer" {
+		t.Errorf("CheapestBuy() = %+v, %v", item, ok)
+	}
+
+	if item, ok := c.BestSell(); !ok || item.Name != "painite" {
+		t.Errorf("BestSell() = %+v, %v", item, ok)
+	}
+}
+
+func TestCommodityHelpersEmpty(t *testing.T) {
+	var c Commodity
+
+	if _, ok := c.Find("gold"); ok {
+		t.Error("Find on an empty market returned ok")
+	}
+
+	if _, ok := c.CheapestBuy(); ok {
+		t.Error("CheapestBuy on an empty market returned ok")
+	}
+
+	if _, ok := c.BestSell(); ok {
+		t.Error("BestSell on an empty market returned ok")
+	}
+}
+
+func TestCommodityStationType(t *testing.T) {
+	result, err := parseJSON(`{
+		"$schemaRef": "https://eddn.edcd.io/schemas/commodity/3",
+		"header": {"uploaderID": "abcdef0123456789", "softwareName": "EDMC",
+			"softwareVersion": "5.1.1"},
+		"message": {
+			"systemName": "Eranin",
+			"stationName": "Azeban City",
+			"stationType": "Bernal",
+			"timestamp": "2021-05-25T18:40:11Z",
+			"economies": [
+				{"name": "Industrial", "proportion": 0.8},
+				{"name": "Extraction", "proportion": 0.2}
+			],
+			"commodities": []
+		}
+	}`)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	c := result.Message.(Commodity)
+
+	if c.StationType() != StationTypeOcellus {
+		t.Errorf("Unexpected station type: %v", c.StationType())
+	}
+
+	if len(c.Message.Economies) != 2 || c.Message.Economies[0].Name != "Industrial" ||
+		c.Message.Economies[1].Proportion != 0.2 {
+		t.Errorf("Unexpected economies: %+v", c.Message.Economies)
+	}
+
+	if (Commodity{}).StationType() != StationTypeUnknown {
+		t.Errorf("Expected unknown station type when it isn't sent")
+	}
+}
+
+func TestCommodityEconomies(t *testing.T) {
+	result, err := parseJSON(`{
+		"$schemaRef": "https://eddn.edcd.io/schemas/commodity/3",
+		"header": {"uploaderID": "abcdef0123456789", "softwareName": "EDMC",
+			"softwareVersion": "5.1.1"},
+		"message": {
+			"systemName": "Shinrarta Dezhra",
+			"stationName": "Jameson Memorial",
+			"timestamp": "2021-05-25T18:40:11Z",
+			"economies": [
+				{"name": "HighTech", "proportion": 0.5},
+				{"name": "Industrial", "proportion": 0.3},
+				{"name": "Refinery", "proportion": 0.2}
+			],
+			"commodities": []
+		}
+	}`)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	economies := result.Message.(Commodity).Message.Economies
+	expected := []Economy{{"HighTech", 0.5}, {"Industrial", 0.3}, {"Refinery", 0.2}}
+
+	if len(economies) != len(expected) {
+		t.Fatalf("Unexpected economies: %+v", economies)
+	}
+
+	total := 0.0
+
+	for i, economy := range economies {
+		if economy != expected[i] {
+			t.Errorf("Unexpected economy %d: %+v", i, economy)
+		}
+
+		total += economy.Proportion
+	}
+
+	if total < 0.999 || total > 1.001 {
+		t.Errorf("Expected the proportions to total 1, got %f", total)
+	}
+}
+
+func TestCommodityProhibited(t *testing.T) {
+	result, err := parseJSON(`{
+		"$schemaRef": "https://eddn.edcd.io/schemas/commodity/3",
+		"header": {"uploaderID": "abcdef0123456789", "softwareName": "EDMC",
+			"softwareVersion": "5.1.1"},
+		"message": {
+			"systemName": "Eranin",
+			"stationName": "Azeban City",
+			"timestamp": "2021-05-25T18:40:11Z",
+			"prohibited": ["Slaves", "ImperialSlaves", "BattleWeapons"],
+			"commodities": []
+		}
+	}`)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	prohibited := result.Message.(Commodity).Message.Prohibited
+
+	if len(prohibited) != 3 || prohibited[0] != "Slaves" ||
+		prohibited[2] != "BattleWeapons" {
+		t.Errorf("Unexpected prohibited commodities: %v", prohibited)
+	}
+}
@@ -0,0 +1,159 @@
+package EDDNClient
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// dedupCacheSize is the maximum number of messages remembered by EnableDedup.
+// Once full the oldest message is forgotten, even if its TTL hasn't expired.
+const dedupCacheSize = 4096
+
+// dedupKey identifies a message for deduplication.
+type dedupKey struct {
+	schemaRef   string
+	systemName  string
+	stationName string
+	timestamp   string
+}
+
+// dedupEntry is a single message remembered by a dedupCache.
+type dedupEntry struct {
+	key  dedupKey
+	seen time.Time
+}
+
+// dedupCache remembers the messages seen within ttl.  At most size messages
+// are remembered, the oldest being forgotten first.
+type dedupCache struct {
+	mutex   sync.Mutex
+	ttl     time.Duration
+	seen    map[dedupKey]time.Time
+	entries []dedupEntry // Ring of remembered messages in the order seen
+	next    int          // Index of the oldest entry once entries is full
+	size    int
+}
+
+func newDedupCache(ttl time.Duration, size int) *dedupCache {
+	return &dedupCache{
+		ttl:     ttl,
+		seen:    make(map[dedupKey]time.Time, size),
+		entries: make([]dedupEntry, 0, size),
+		size:    size}
+}
+
+// duplicate reports whether key was seen within the TTL of now, remembering
+// it if not.
+func (c *dedupCache) duplicate(key dedupKey, now time.Time) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if seen, ok := c.seen[key]; ok && now.Sub(seen) < c.ttl {
+		return true
+	}
+
+	entry := dedupEntry{key, now}
+
+	if len(c.entries) < c.size {
+		c.entries = append(c.entries, entry)
+	} else {
+		oldest := c.entries[c.next]
+
+		// The key may have been seen again since, in which case the newer
+		// entry is still in the ring.
+		if c.seen[oldest.key].Equal(oldest.seen) {
+			delete(c.seen, oldest.key)
+		}
+
+		c.entries[c.next] = entry
+		c.next = (c.next + 1) % c.size
+	}
+
+	c.seen[key] = now
+
+	return false
+}
+
+// dedupSchemas contains the names of the schemas whose messages are
+// deduplicated.  Each of them describes a single market, so two messages with
+// the same system, station, and timestamp are the same upload.  Other schemas,
+// such as the journal, may carry several different messages for the same
+// system at the same time.  i.e. a Scan of each of its bodies.
+var dedupSchemas = map[string]bool{
+	"commodity":   true,
+	"outfitting":  true,
+	"shipyard":    true,
+	"blackmarket": true,
+}
+
+// dedupFields contains the fields of a message used for deduplication.
+type dedupFields struct {
+	SystemName  string `json:"systemName"`
+	StationName string `json:"stationName"`
+	Timestamp   string `json:"timestamp"`
+}
+
+// messageDedupKey returns the key of root.  ok is false if the message isn't
+// from one of the dedupSchemas, or has none of the fields used as a key and so
+// can't be deduplicated.
+func messageDedupKey(root Root) (key dedupKey, ok bool) {
+	name, _, err := schemaKey(root.SchemaRef)
+
+	if err != nil || !dedupSchemas[name] {
+		return dedupKey{}, false
+	}
+
+	var fields dedupFields
+
+	if err := json.Unmarshal(root.Message, &fields); err != nil {
+		return dedupKey{}, false
+	}
+
+	if fields.SystemName == "" && fields.StationName == "" &&
+		fields.Timestamp == "" {
+		return dedupKey{}, false
+	}
+
+	return dedupKey{
+		schemaRef:   root.SchemaRef,
+		systemName:  fields.SystemName,
+		stationName: fields.StationName,
+		timestamp:   fields.Timestamp}, true
+}
+
+// EnableDedup disregards market messages, i.e. commodity, outfitting,
+// shipyard, and blackmarket, with the same schema, system name, station name,
+// and timestamp as one received within ttl.  This is common with market data
+// as several tools often upload the same data seconds apart.  Other schemas
+// are never deduplicated.  At most the last 4096 messages are remembered.
+// Each message disregarded is counted as a Duplicate in Stats.  Passing a ttl
+// of 0 disables deduplication.
+func (ci *ChannelInterface) EnableDedup(ttl time.Duration) {
+	var cache *dedupCache
+
+	if ttl > 0 {
+		cache = newDedupCache(ttl, dedupCacheSize)
+	}
+
+	ci.filterLock.Lock()
+	defer ci.filterLock.Unlock()
+
+	ci.dedup = cache
+}
+
+// duplicate reports whether root is a duplicate of a message received within
+// the TTL given to EnableDedup.
+func (ci *ChannelInterface) duplicate(root Root) bool {
+	ci.filterLock.RLock()
+	cache := ci.dedup
+	ci.filterLock.RUnlock()
+
+	if cache == nil {
+		return false
+	}
+
+	key, ok := messageDedupKey(root)
+
+	return ok && cache.duplicate(key, time.Now())
+}
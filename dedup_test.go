@@ -0,0 +1,87 @@
+package EDDNClient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedupCache(t *testing.T) {
+	now := time.Now()
+	cache := newDedupCache(time.Minute, 2)
+	a := dedupKey{schemaRef: "commodity/3", systemName: "Eranin"}
+	b := dedupKey{schemaRef: "commodity/3", systemName: "Sol"}
+	c := dedupKey{schemaRef: "commodity/3", systemName: "Lave"}
+
+	if cache.duplicate(a, now) {
+		t.Fatalf("Expected first message not to be a duplicate")
+	}
+
+	if !cache.duplicate(a, now.Add(time.Second)) {
+		t.Errorf("Expected message within the TTL to be a duplicate")
+	}
+
+	if cache.duplicate(a, now.Add(2*time.Minute)) {
+		t.Errorf("Expected message after the TTL not to be a duplicate")
+	}
+
+	// a is in the ring twice now, so b evicts the older entry and c the
+	// newer.
+	cache.duplicate(b, now.Add(2*time.Minute))
+
+	if !cache.duplicate(a, now.Add(2*time.Minute)) {
+		t.Errorf("Expected newer entry to survive eviction of the older")
+	}
+
+	cache.duplicate(c, now.Add(2*time.Minute))
+
+	if len(cache.seen) > 2 {
+		t.Errorf("Expected at most 2 messages remembered, got %d", len(cache.seen))
+	}
+}
+
+func TestChannelInterfaceDedup(t *testing.T) {
+	ci := newChannelInterface(FilterNone,
+		ChannelInterfaceConfig{BufferSize: 4}.withDefaults())
+	other := strings.Replace(commodity2Fixture, "Azeban Orbital", "Other Orbital", 1)
+
+	ci.EnableDedup(time.Minute)
+
+	ci.handleMessage(context.Background(), compress(t, commodity2Fixture))
+	ci.handleMessage(context.Background(), compress(t, commodity2Fixture))
+	ci.handleMessage(context.Background(), compress(t, other))
+
+	if stats := ci.Stats(); stats.Parsed != 2 || stats.Duplicates != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	ci.EnableDedup(0)
+
+	ci.handleMessage(context.Background(), compress(t, commodity2Fixture))
+
+	if stats := ci.Stats(); stats.Parsed != 3 || stats.Duplicates != 1 {
+		t.Errorf("Unexpected stats after disabling dedup: %+v", stats)
+	}
+}
+
+func TestChannelInterfaceDedupJournal(t *testing.T) {
+	ci := newChannelInterface(FilterNone,
+		ChannelInterfaceConfig{BufferSize: 4}.withDefaults())
+	scan := `{"timestamp": "2021-05-25T18:06:08Z", "event": "Scan",
+		"StarSystem": "Eranin", "BodyName": "%s", "BodyID": %d}`
+
+	ci.EnableDedup(time.Minute)
+
+	// Each body of a system is scanned at once, so these share a system, and
+	// timestamp but are different messages.
+	ci.handleMessage(context.Background(), compress(t,
+		journalFixture(fmt.Sprintf(scan, "Eranin 1", 1))))
+	ci.handleMessage(context.Background(), compress(t,
+		journalFixture(fmt.Sprintf(scan, "Eranin 2", 2))))
+
+	if stats := ci.Stats(); stats.Parsed != 2 || stats.Duplicates != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,220 @@
+package EDDNClient
+
+import (
+	"reflect"
+)
+
+// On registers fn as the handler for messages of type t, which are then
+// passed to fn rather than being sent on their channel.  This includes the
+// types of any schema registered with RegisterSchema, which have no channel
+// of their own.  Handlers are called from the ChannelInterface goroutines,
+// concurrently when there is more than one worker, and a slow handler delays
+// every message just as a full channel does.  Passing a nil fn removes the
+// handler.
+func (ci *ChannelInterface) On(t reflect.Type, fn func(msg interface{})) {
+	ci.handlerLock.Lock()
+	defer ci.handlerLock.Unlock()
+
+	if fn == nil {
+		delete(ci.handlers, t)
+		return
+	}
+
+	if ci.handlers == nil {
+		ci.handlers = make(map[reflect.Type]func(interface{}))
+	}
+
+	ci.handlers[t] = fn
+}
+
+// OnDefault registers fn as the handler for every message without a handler
+// registered by On.  Once set no messages are sent on the channels.  Passing
+// a nil fn removes the handler.
+func (ci *ChannelInterface) OnDefault(fn func(msg interface{})) {
+	ci.handlerLock.Lock()
+	defer ci.handlerLock.Unlock()
+
+	ci.defaultHandler = fn
+}
+
+// OnJournal registers fn as the handler for Journal messages.
+func (ci *ChannelInterface) OnJournal(fn func(Journal)) {
+	if fn == nil {
+		ci.On(reflect.TypeOf(Journal{}), nil)
+		return
+	}
+
+	ci.On(reflect.TypeOf(Journal{}), func(msg interface{}) { fn(msg.(Journal)) })
+}
+
+// OnShipyard registers fn as the handler for Shipyard messages.
+func (ci *ChannelInterface) OnShipyard(fn func(Shipyard)) {
+	if fn == nil {
+		ci.On(reflect.TypeOf(Shipyard{}), nil)
+		return
+	}
+
+	ci.On(reflect.TypeOf(Shipyard{}), func(msg interface{}) { fn(msg.(Shipyard)) })
+}
+
+// OnCommodity registers fn as the handler for Commodity messages.
+func (ci *ChannelInterface) OnCommodity(fn func(Commodity)) {
+	if fn == nil {
+		ci.On(reflect.TypeOf(Commodity{}), nil)
+		return
+	}
+
+	ci.On(reflect.TypeOf(Commodity{}), func(msg interface{}) { fn(msg.(Commodity)) })
+}
+
+// OnBlackmarket registers fn as the handler for Blackmarket messages.
+func (ci *ChannelInterface) OnBlackmarket(fn func(Blackmarket)) {
+	if fn == nil {
+		ci.On(reflect.TypeOf(Blackmarket{}), nil)
+		return
+	}
+
+	ci.On(reflect.TypeOf(Blackmarket{}), func(msg interface{}) { fn(msg.(Blackmarket)) })
+}
+
+// OnOutfitting registers fn as the handler for Outfitting messages.
+func (ci *ChannelInterface) OnOutfitting(fn func(Outfitting)) {
+	if fn == nil {
+		ci.On(reflect.TypeOf(Outfitting{}), nil)
+		return
+	}
+
+	ci.On(reflect.TypeOf(Outfitting{}), func(msg interface{}) { fn(msg.(Outfitting)) })
+}
+
+// OnApproachSettlement registers fn as the handler for ApproachSettlement
+// messages.
+func (ci *ChannelInterface) OnApproachSettlement(fn func(ApproachSettlement)) {
+	if fn == nil {
+		ci.On(reflect.TypeOf(ApproachSettlement{}), nil)
+		return
+	}
+
+	ci.On(reflect.TypeOf(ApproachSettlement{}), func(msg interface{}) {
+		fn(msg.(ApproachSettlement))
+	})
+}
+
+// OnNavRoute registers fn as the handler for NavRoute messages.
+func (ci *ChannelInterface) OnNavRoute(fn func(NavRoute)) {
+	if fn == nil {
+		ci.On(reflect.TypeOf(NavRoute{}), nil)
+		return
+	}
+
+	ci.On(reflect.TypeOf(NavRoute{}), func(msg interface{}) { fn(msg.(NavRoute)) })
+}
+
+// OnFSSSignalDiscovered registers fn as the handler for FSSSignalDiscovered
+// messages.
+func (ci *ChannelInterface) OnFSSSignalDiscovered(fn func(FSSSignalDiscovered)) {
+	if fn == nil {
+		ci.On(reflect.TypeOf(FSSSignalDiscovered{}), nil)
+		return
+	}
+
+	ci.On(reflect.TypeOf(FSSSignalDiscovered{}), func(msg interface{}) {
+		fn(msg.(FSSSignalDiscovered))
+	})
+}
+
+// OnCodexEntry registers fn as the handler for CodexEntry messages.
+func (ci *ChannelInterface) OnCodexEntry(fn func(CodexEntry)) {
+	if fn == nil {
+		ci.On(reflect.TypeOf(CodexEntry{}), nil)
+		return
+	}
+
+	ci.On(reflect.TypeOf(CodexEntry{}), func(msg interface{}) { fn(msg.(CodexEntry)) })
+}
+
+// OnFSSBodySignals registers fn as the handler for FSSBodySignals messages.
+func (ci *ChannelInterface) OnFSSBodySignals(fn func(FSSBodySignals)) {
+	if fn == nil {
+		ci.On(reflect.TypeOf(FSSBodySignals{}), nil)
+		return
+	}
+
+	ci.On(reflect.TypeOf(FSSBodySignals{}), func(msg interface{}) {
+		fn(msg.(FSSBodySignals))
+	})
+}
+
+// OnScanBaryCentre registers fn as the handler for ScanBaryCentre messages.
+func (ci *ChannelInterface) OnScanBaryCentre(fn func(ScanBaryCentre)) {
+	if fn == nil {
+		ci.On(reflect.TypeOf(ScanBaryCentre{}), nil)
+		return
+	}
+
+	ci.On(reflect.TypeOf(ScanBaryCentre{}), func(msg interface{}) {
+		fn(msg.(ScanBaryCentre))
+	})
+}
+
+// OnFCMaterials registers fn as the handler for FCMaterials messages from
+// either source.
+func (ci *ChannelInterface) OnFCMaterials(fn func(FCMaterials)) {
+	if fn == nil {
+		ci.On(reflect.TypeOf(FCMaterials{}), nil)
+		return
+	}
+
+	ci.On(reflect.TypeOf(FCMaterials{}), func(msg interface{}) {
+		fn(msg.(FCMaterials))
+	})
+}
+
+// OnUnknown registers fn as the handler for messages using a schema that
+// isn't supported.  (Only with DeliverUnknown.)
+func (ci *ChannelInterface) OnUnknown(fn func(RawUnknown)) {
+	if fn == nil {
+		ci.On(reflect.TypeOf(RawUnknown{}), nil)
+		return
+	}
+
+	ci.On(reflect.TypeOf(RawUnknown{}), func(msg interface{}) { fn(msg.(RawUnknown)) })
+}
+
+// dispatchHandled passes msg to the handler registered by On for its type.
+// Unlike dispatch the default handler isn't used, and it reports false if msg
+// has no handler of its own.
+func (ci *ChannelInterface) dispatchHandled(msg interface{}) bool {
+	ci.handlerLock.RLock()
+	fn := ci.handlers[reflect.TypeOf(msg)]
+	ci.handlerLock.RUnlock()
+
+	if fn == nil {
+		return false
+	}
+
+	fn(msg)
+
+	return true
+}
+
+// dispatch passes msg to its handler, or the default handler if it has none.
+// It reports false if there is neither.
+func (ci *ChannelInterface) dispatch(msg interface{}) bool {
+	ci.handlerLock.RLock()
+	fn, ok := ci.handlers[reflect.TypeOf(msg)]
+
+	if !ok {
+		fn = ci.defaultHandler
+	}
+
+	ci.handlerLock.RUnlock()
+
+	if fn == nil {
+		return false
+	}
+
+	fn(msg)
+
+	return true
+}
@@ -0,0 +1,70 @@
+package EDDNClient
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestChannelInterfaceDispatch(t *testing.T) {
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{}.withDefaults())
+
+	var commodities []Commodity
+	var others []interface{}
+
+	ci.OnCommodity(func(c Commodity) { commodities = append(commodities, c) })
+
+	// The channels are unbuffered, and unread, so anything sent on them
+	// would block.
+	ci.handleMessage(context.Background(), compress(t, commodity2Fixture))
+
+	if len(commodities) != 1 || commodities[0].Message.StationName != "Azeban Orbital" {
+		t.Fatalf("Unexpected commodities: %+v", commodities)
+	}
+
+	ci.OnDefault(func(msg interface{}) { others = append(others, msg) })
+	ci.handleMessage(context.Background(), compress(t, outfitting1Fixture))
+
+	if len(others) != 1 {
+		t.Fatalf("Expected 1 message for the default handler, got %d", len(others))
+	}
+
+	if _, ok := others[0].(Outfitting); !ok {
+		t.Errorf("Expected Outfitting, got %T", others[0])
+	}
+
+	// Without its handler commodities fall back to the default handler.
+	ci.OnCommodity(nil)
+	ci.handleMessage(context.Background(), compress(t, commodity2Fixture))
+
+	if len(commodities) != 1 || len(others) != 2 {
+		t.Errorf("Unexpected dispatch: %d commodities, %d others",
+			len(commodities), len(others))
+	}
+}
+
+type dispatchTestMessage struct {
+	SchemaRef string
+}
+
+func TestChannelInterfaceDispatchRegisteredSchema(t *testing.T) {
+	RegisterSchema("dispatchtest/1", func(raw []byte) (interface{}, error) {
+		return dispatchTestMessage{"dispatchtest/1"}, nil
+	})
+	defer RegisterSchema("dispatchtest/1", nil)
+
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{}.withDefaults())
+
+	var received []interface{}
+
+	ci.On(reflect.TypeOf(dispatchTestMessage{}), func(msg interface{}) {
+		received = append(received, msg)
+	})
+
+	ci.handleMessage(context.Background(),
+		`{"$schemaRef": "dispatchtest/1", "header": {}, "message": {}}`)
+
+	if len(received) != 1 {
+		t.Errorf("Expected registered schema to be dispatched, got %v", received)
+	}
+}
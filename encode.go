@@ -0,0 +1,46 @@
+package EDDNClient
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// schemaRefPrefix is prepended to schemas given to Encode as just a name and
+// version.
+const schemaRefPrefix = "https://eddn.edcd.io/schemas/"
+
+// envelope is a message ready to be encoded.  It's Root with a message that
+// hasn't been encoded yet.
+type envelope struct {
+	SchemaRef string      `json:"$schemaRef"`
+	Header    Header      `json:"header"`
+	Message   interface{} `json:"message"`
+}
+
+// Encode encodes msg as a zlib compressed frame exactly as EDDN sends it,
+// which is the inverse of ParseMessage.  schema may be a full schema
+// reference, or just the name and version.  i.e. "commodity/3".  msg is the
+// Message of one of the message types, such as the CommodityMessage of a
+// Commodity, or a journal event.
+//
+// Older schemas are converted to the current version when parsed, so a
+// Commodity parsed from a "commodity/2" message should be encoded as
+// "commodity/3".  The frames may be replayed with NewMockChannelInterface,
+// or published by a relay of the receiver's own.
+func Encode(schema string, header Header, msg interface{}) ([]byte, error) {
+	if _, _, err := schemaKey(schema); err != nil {
+		return nil, err
+	}
+
+	if !strings.Contains(schema, "://") {
+		schema = schemaRefPrefix + schema
+	}
+
+	data, err := json.Marshal(envelope{schema, header, msg})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return CompressFrame(string(data)), nil
+}
@@ -0,0 +1,83 @@
+package EDDNClient
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeRoundTrip(t *testing.T) {
+	fixtures := []string{
+		marketFixture(3),
+		navRouteFixture,
+		codexEntryFixture,
+		journalFixture(`{
+			"timestamp": "2017-01-01T12:00:00Z",
+			"event": "FSDJump",
+			"StarSystem": "Pleione",
+			"SystemAddress": 2862335682961,
+			"StarPos": [-77.0, -146.78125, -344.125]
+		}`),
+	}
+
+	for _, fixture := range fixtures {
+		original, err := parseJSON(fixture)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		// Every message type has a Message field holding what's encoded.
+		msg := reflect.ValueOf(original.Message).FieldByName("Message").Interface()
+
+		frame, err := Encode(original.SchemaRef, original.Header, msg)
+
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", original.SchemaRef, err)
+		}
+
+		if !isZlib(frame) {
+			t.Errorf("%s: expected a zlib compressed frame", original.SchemaRef)
+		}
+
+		decoded, err := ParseMessage(frame)
+
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", original.SchemaRef, err)
+		}
+
+		if !reflect.DeepEqual(decoded, original) {
+			t.Errorf("%s: round trip changed the message\nfrom %+v\nto   %+v",
+				original.SchemaRef, original, decoded)
+		}
+	}
+}
+
+func TestEncodeShortSchema(t *testing.T) {
+	frame, err := Encode("navroute/1", Header{SoftwareName: "Test"},
+		NavRouteMessage{Event: "NavRoute", Timestamp: "2021-05-25T18:30:02Z"})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result, err := ParseMessage(frame)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.SchemaRef != "https://eddn.edcd.io/schemas/navroute/1" {
+		t.Errorf("Unexpected schema: %s", result.SchemaRef)
+	}
+
+	if _, ok := result.Message.(NavRoute); !ok {
+		t.Errorf("Expected NavRoute, got %T", result.Message)
+	}
+}
+
+func TestEncodeMalformedSchema(t *testing.T) {
+	if _, err := Encode("navroute", Header{}, nil); !errors.Is(err, errMalformedSchema) {
+		t.Errorf("Expected a malformed schema error, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package EDDNClient
+
+import (
+	"time"
+)
+
+// connEventBuffer is the number of ConnEvents buffered before more are
+// dropped.
+const connEventBuffer = 32
+
+// ConnEventType describes what happened to the connection of a
+// ChannelInterface to the relay.
+type ConnEventType int
+
+// The kinds of ConnEvent sent by a ChannelInterface.
+const (
+	EventConnected    ConnEventType = iota // Connected to the relay
+	EventDisconnected                      // Connection lost, or went idle
+	EventReconnecting                      // About to wait, and reconnect
+	EventError                             // An attempt to reconnect failed
+)
+
+func (t ConnEventType) String() string {
+	switch t {
+	case EventConnected:
+		return "connected"
+	case EventDisconnected:
+		return "disconnected"
+	case EventReconnecting:
+		return "reconnecting"
+	case EventError:
+		return "error"
+	}
+
+	return "unknown"
+}
+
+// ConnEvent is sent on the channel returned by Events whenever the
+// connection of a ChannelInterface to the relay changes.
+type ConnEvent struct {
+	Type    ConnEventType
+	Time    time.Time     // When it happened
+	Relay   string        // Address of the relay
+	Attempt int           // Reconnection attempt from 1, or 0 when first connected
+	Delay   time.Duration // Delay before the reconnection attempt is made
+	Err     error         // Why it was disconnected, or failed to reconnect
+}
+
+// Events returns the channel connection events are sent on when ConnEvents
+// is set in the ChannelInterfaceConfig, or nil otherwise.  Events are never
+// waited for, so those sent while the channel is full are dropped.  It's
+// closed along with the other channels.
+func (ci *ChannelInterface) Events() <-chan ConnEvent {
+	return ci.events
+}
+
+// emit sends event on the events channel of ci, if it has one, without
+// blocking.
+func (ci *ChannelInterface) emit(event ConnEvent) {
+	if ci.events == nil {
+		return
+	}
+
+	event.Time = time.Now()
+
+	select {
+	case ci.events <- event:
+	default:
+	}
+}
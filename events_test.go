@@ -0,0 +1,117 @@
+package EDDNClient
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// nextEvent returns the next ConnEvent sent by ci.
+func nextEvent(t *testing.T, ci *ChannelInterface) ConnEvent {
+	select {
+	case event := <-ci.Events():
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatalf("No ConnEvent received")
+	}
+
+	return ConnEvent{}
+}
+
+func TestConnEventsReconnect(t *testing.T) {
+	// The relay hangs up straight away, so the ChannelInterface reconnects.
+	server := httptest.NewServer(websocketRelay(t, func(conn net.Conn, rw *bufio.ReadWriter) {
+		writeServerFrame(rw, true, websocketClose, nil)
+		rw.Flush()
+	}))
+
+	defer server.Close()
+
+	address := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	ci, err := NewChannelInterfaceWithConfig(FilterNone, ChannelInterfaceConfig{
+		Address:           address,
+		ReconnectMinDelay: time.Millisecond,
+		ConnEvents:        true})
+
+	if err != nil {
+		t.Fatalf("NewChannelInterfaceWithConfig: %v", err)
+	}
+
+	defer ci.Close()
+
+	want := []ConnEvent{
+		{Type: EventConnected, Relay: address},
+		{Type: EventDisconnected, Relay: address, Err: errWebsocketClosed},
+		{Type: EventReconnecting, Attempt: 1, Delay: time.Millisecond},
+		{Type: EventConnected, Relay: address, Attempt: 1}}
+
+	for _, expected := range want {
+		event := nextEvent(t, ci)
+
+		if event.Time.IsZero() {
+			t.Errorf("Expected %v to have a time", event.Type)
+		}
+
+		event.Time = time.Time{}
+
+		if event != expected {
+			t.Errorf("Expected %+v, got %+v", expected, event)
+		}
+	}
+}
+
+func TestConnEventsError(t *testing.T) {
+	// Nothing is listening on this port, so every attempt fails.
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{
+		Address:           "ws://127.0.0.1:1",
+		ReconnectMinDelay: time.Millisecond,
+		ReconnectMaxDelay: 2 * time.Millisecond,
+		ConnEvents:        true}.withDefaults())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan bool)
+
+	go func() {
+		stopped <- ci.reconnect(ctx, errIdle)
+	}()
+
+	if event := nextEvent(t, ci); event.Type != EventDisconnected || event.Err != errIdle {
+		t.Errorf("Expected disconnected by errIdle, got %+v", event)
+	}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		event := nextEvent(t, ci)
+
+		if event.Type != EventReconnecting || event.Attempt != attempt ||
+			event.Delay != time.Duration(attempt)*time.Millisecond {
+			t.Errorf("Unexpected event for attempt %d: %+v", attempt, event)
+		}
+
+		if event = nextEvent(t, ci); event.Type != EventError ||
+			event.Attempt != attempt || event.Err == nil {
+			t.Errorf("Expected an error for attempt %d, got %+v", attempt, event)
+		}
+	}
+
+	cancel()
+
+	if <-stopped {
+		t.Errorf("Expected reconnect to give up once cancelled")
+	}
+}
+
+func TestConnEventsDisabled(t *testing.T) {
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{}.withDefaults())
+
+	if ci.Events() != nil {
+		t.Errorf("Expected no events channel without ConnEvents")
+	}
+
+	// Nothing to send on, which must not block.
+	ci.emit(ConnEvent{Type: EventConnected})
+}
@@ -0,0 +1,39 @@
+package EDDNClient_test
+
+import (
+	"fmt"
+	eddn "github.com/mbsmith/EDDNClient"
+	"log"
+)
+
+func ExampleParseMessage() {
+	data := []byte(`{
+		"$schemaRef": "https://eddn.edcd.io/schemas/shipyard/2",
+		"header": {
+			"uploaderID": "me",
+			"softwareName": "mysoftware",
+			"softwareVersion": "1.0"
+		},
+		"message": {
+			"systemName": "Eranin",
+			"stationName": "Azeban Orbital",
+			"timestamp": "2017-01-01T12:00:00Z",
+			"ships": ["SideWinder", "Eagle"]
+		}
+	}`)
+
+	result, err := eddn.ParseMessage(data)
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if shipyard, ok := result.Message.(eddn.Shipyard); ok {
+		fmt.Printf("Uploader: %s\n", result.Header.UploaderID)
+		fmt.Printf("Ships: %v", shipyard.Message.Ships)
+	}
+
+	// Output:
+	// Uploader: me
+	// Ships: [SideWinder Eagle]
+}
@@ -13,10 +13,12 @@ func ExampleSendJournalFSDJump() {
 	}
 
 	journalMsg := &eddn.JournalFSDJump{
-		Event:      "FSDJump",
-		StarPos:    []float64{33.3, 33.4, 33.5},
-		StarSystem: "none",
-		Timestamp:  eddn.GenerateUTCDateTime()}
+		CommonJournal: eddn.CommonJournal{
+			JournalSystem: eddn.JournalSystem{
+				StarPos:    eddn.StarPos{X: 33.3, Y: 33.4, Z: 33.5},
+				StarSystem: "none"},
+			Event:     "FSDJump",
+			Timestamp: eddn.GenerateUTCDateTime()}}
 
 	err = uploader.SendJournalFSDJump(journalMsg)
 
@@ -52,7 +52,7 @@ var prettyPrint = flag.Bool("pretty-print", false, "Pretty print JSON we receive
 func init() {
 	// Tie the filter flag to filter
 	flag.Var(&filterFlag, "filters",
-		"comma-separated values of results to filter. [outfitting, journal, shipyard, commodity, and blackmarket]")
+		"comma-separated values of results to filter. [outfitting, journal, shipyard, commodity, blackmarket, approachsettlement, navroute, fsssignaldiscovered, codexentry, fssbodysignals, scanbarycentre, and fcmaterials]")
 }
 
 func output(data []byte) {
@@ -93,6 +93,34 @@ func handleConnections(channelInterface *eddn.ChannelInterface) {
 		case shipMessage := <-channelInterface.ShipyardChan:
 			b, _ := json.Marshal(shipMessage)
 			output(b)
+
+		case settlementMessage := <-channelInterface.ApproachSettlementChan:
+			b, _ := json.Marshal(settlementMessage)
+			output(b)
+
+		case navRouteMessage := <-channelInterface.NavRouteChan:
+			b, _ := json.Marshal(navRouteMessage)
+			output(b)
+
+		case signalMessage := <-channelInterface.FSSSignalDiscoveredChan:
+			b, _ := json.Marshal(signalMessage)
+			output(b)
+
+		case codexEntryMessage := <-channelInterface.CodexEntryChan:
+			b, _ := json.Marshal(codexEntryMessage)
+			output(b)
+
+		case fssBodySignalsMessage := <-channelInterface.FSSBodySignalsChan:
+			b, _ := json.Marshal(fssBodySignalsMessage)
+			output(b)
+
+		case scanBaryCentreMessage := <-channelInterface.ScanBaryCentreChan:
+			b, _ := json.Marshal(scanBaryCentreMessage)
+			output(b)
+
+		case fcMaterialsMessage := <-channelInterface.FCMaterialsChan:
+			b, _ := json.Marshal(fcMaterialsMessage)
+			output(b)
 		}
 	}
 }
@@ -116,6 +144,20 @@ func main() {
 			filters |= eddn.FilterCommodity
 		case "blackmarket":
 			filters |= eddn.FilterBlackmarket
+		case "approachsettlement":
+			filters |= eddn.FilterApproachSettlement
+		case "navroute":
+			filters |= eddn.FilterNavRoute
+		case "fsssignaldiscovered":
+			filters |= eddn.FilterFSSSignalDiscovered
+		case "codexentry":
+			filters |= eddn.FilterCodexEntry
+		case "fssbodysignals":
+			filters |= eddn.FilterFSSBodySignals
+		case "scanbarycentre":
+			filters |= eddn.FilterScanBaryCentre
+		case "fcmaterials":
+			filters |= eddn.FilterFCMaterials
 		default:
 			log.Printf("%s is not a valid filter", filter)
 			continue
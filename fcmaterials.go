@@ -0,0 +1,42 @@
+package EDDNClient
+
+// FCMaterialsSource describes where the materials of a fleet carrier bar
+// were read from, as each has its own schema.
+type FCMaterialsSource int
+
+// The sources of FCMaterials messages.
+const (
+	FCMaterialsJournal FCMaterialsSource = iota // The journal.  (fcmaterials_journal)
+	FCMaterialsCAPI                             // The Frontier CAPI.  (fcmaterials_capi)
+)
+
+// FCMaterialsItem is a single material traded at the bar of a fleet carrier.
+// A carrier either sells a material, with Stock, or buys it, with Demand.
+type FCMaterialsItem struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"Name"`
+	NameLocalised string `json:"Name_Localised,omitempty"`
+	Price         int    `json:"Price"`
+	Stock         int    `json:"Stock"`
+	Demand        int    `json:"Demand"`
+}
+
+// FCMaterialsMessage contains the materials traded at the bar of a fleet
+// carrier sent to EDDN.  CarrierName is only sent by the journal.
+type FCMaterialsMessage struct {
+	CarrierID   string            `json:"CarrierID"` // Required
+	CarrierName string            `json:"CarrierName,omitempty"`
+	Event       string            `json:"event"`     // Required
+	Items       []FCMaterialsItem `json:"Items"`     // Required
+	MarketID    int64             `json:"MarketID"`  // Required
+	Timestamp   string            `json:"timestamp"` // Required
+}
+
+// FCMaterials is the high level type that contains the entire JSON message
+// of either schema, along with its Source.
+type FCMaterials struct {
+	SchemaRef string             `json:"$schemaRef"`
+	Header    Header             `json:"header"`
+	Message   FCMaterialsMessage `json:"message"`
+	Source    FCMaterialsSource  `json:"-"`
+}
@@ -0,0 +1,91 @@
+package EDDNClient
+
+// headerFilter accepts, or disregards messages based on a single header
+// field using allow and deny lists.  A nil headerFilter accepts everything.
+type headerFilter struct {
+	allow map[string]bool
+	deny  map[string]bool
+}
+
+// newHeaderFilter creates a headerFilter from the given lists.  If both are
+// empty nil is returned.
+func newHeaderFilter(allow, deny []string) *headerFilter {
+	if len(allow) == 0 && len(deny) == 0 {
+		return nil
+	}
+
+	f := &headerFilter{}
+
+	if len(allow) > 0 {
+		f.allow = make(map[string]bool, len(allow))
+
+		for _, value := range allow {
+			f.allow[value] = true
+		}
+	}
+
+	if len(deny) > 0 {
+		f.deny = make(map[string]bool, len(deny))
+
+		for _, value := range deny {
+			f.deny[value] = true
+		}
+	}
+
+	return f
+}
+
+// accepts reports whether value passes f.  Denied values are always
+// disregarded, and if there is an allow list value must be in it.
+func (f *headerFilter) accepts(value string) bool {
+	if f == nil {
+		return true
+	}
+
+	if f.deny[value] {
+		return false
+	}
+
+	return f.allow == nil || f.allow[value]
+}
+
+// SetSoftwareFilter filters messages by the softwareName in their header.
+// Messages from software in deny are disregarded, and if allow isn't empty
+// only messages from software in it are delivered.  Passing nil for both
+// removes the filter.  The header is checked before the message is decoded,
+// and each message disregarded is counted by Filtered().
+func (ci *ChannelInterface) SetSoftwareFilter(allow, deny []string) {
+	f := newHeaderFilter(allow, deny)
+
+	ci.filterLock.Lock()
+	defer ci.filterLock.Unlock()
+
+	ci.softwareFilter = f
+}
+
+// SetUploaderFilter is the same as SetSoftwareFilter, but filters messages by
+// the uploaderID in their header instead.
+func (ci *ChannelInterface) SetUploaderFilter(allow, deny []string) {
+	f := newHeaderFilter(allow, deny)
+
+	ci.filterLock.Lock()
+	defer ci.filterLock.Unlock()
+
+	ci.uploaderFilter = f
+}
+
+// acceptHeader reports whether header passes the software, and uploader
+// filters of ci.
+func (ci *ChannelInterface) acceptHeader(header Header) bool {
+	ci.filterLock.RLock()
+	defer ci.filterLock.RUnlock()
+
+	return ci.softwareFilter.accepts(header.SoftwareName) &&
+		ci.uploaderFilter.accepts(header.UploaderID)
+}
+
+// Filtered returns the number of messages disregarded by the software, and
+// uploader filters since ci was created.
+func (ci *ChannelInterface) Filtered() uint64 {
+	return ci.filtered.Load()
+}
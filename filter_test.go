@@ -0,0 +1,74 @@
+package EDDNClient
+
+import (
+	"testing"
+)
+
+func TestHeaderFilter(t *testing.T) {
+	var f *headerFilter
+
+	if !f.accepts("anything") {
+		t.Errorf("Expected nil filter to accept everything")
+	}
+
+	if newHeaderFilter(nil, nil) != nil {
+		t.Errorf("Expected empty lists to give a nil filter")
+	}
+
+	f = newHeaderFilter([]string{"EDMC", "EDDiscovery"}, []string{"EDDiscovery"})
+
+	if !f.accepts("EDMC") {
+		t.Errorf("Expected allowed value to be accepted")
+	}
+
+	if f.accepts("EDDiscovery") {
+		t.Errorf("Expected denied value to be disregarded even when allowed")
+	}
+
+	if f.accepts("Other") {
+		t.Errorf("Expected value missing from the allow list to be disregarded")
+	}
+
+	f = newHeaderFilter(nil, []string{"Broken Uploader"})
+
+	if !f.accepts("EDMC") || f.accepts("Broken Uploader") {
+		t.Errorf("Expected deny list to only disregard denied values")
+	}
+}
+
+func TestChannelInterfaceHeaderFilters(t *testing.T) {
+	root := Root{
+		SchemaRef: "https://eddn.edcd.io/schemas/commodity/3",
+		Header: Header{
+			SoftwareName: "Broken Uploader",
+			UploaderID:   "abcdef0123456789"}}
+
+	ci := &ChannelInterface{}
+
+	if !ci.accept(root) {
+		t.Fatalf("Expected message to be accepted without filters")
+	}
+
+	ci.SetSoftwareFilter(nil, []string{"Broken Uploader"})
+
+	if ci.accept(root) {
+		t.Errorf("Expected denied software to be disregarded")
+	}
+
+	ci.SetSoftwareFilter(nil, nil)
+	ci.SetUploaderFilter([]string{"0123456789abcdef"}, nil)
+
+	if ci.accept(root) {
+		t.Errorf("Expected uploader missing from the allow list to be disregarded")
+	}
+
+	ci.SetUploaderFilter(nil, nil)
+
+	if !ci.accept(root) {
+		t.Errorf("Expected message to be accepted once the filters are removed")
+	}
+
+	if ci.Filtered() != 2 {
+		t.Errorf("Expected 2 filtered messages, got %d", ci.Filtered())
+	}
+}
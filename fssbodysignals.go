@@ -0,0 +1,23 @@
+package EDDNClient
+
+// FSSBodySignalsMessage contains the signals detected on a body from orbit
+// by the full spectrum scanner, before it has been mapped, sent to EDDN.  Each
+// Signal is a count of a type of signal.  i.e. "$SAA_SignalType_Biological;".
+type FSSBodySignalsMessage struct {
+	BodyID        int      `json:"BodyID"`        // Required
+	BodyName      string   `json:"BodyName"`      // Required
+	Event         string   `json:"event"`         // Required
+	Signals       []Signal `json:"Signals"`       // Required
+	StarPos       StarPos  `json:"StarPos"`       // Required
+	StarSystem    string   `json:"StarSystem"`    // Required
+	SystemAddress int64    `json:"SystemAddress"` // Required
+	Timestamp     string   `json:"timestamp"`     // Required
+}
+
+// FSSBodySignals is the high level type that contains the entire JSON
+// message.
+type FSSBodySignals struct {
+	SchemaRef string                `json:"$schemaRef"`
+	Header    Header                `json:"header"`
+	Message   FSSBodySignalsMessage `json:"message"`
+}
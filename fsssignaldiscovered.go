@@ -0,0 +1,37 @@
+package EDDNClient
+
+// FSSSignal describes a single signal found by the full spectrum scanner.
+// Only stations, and fleet carriers have IsStation set.  USSType,
+// SpawningState, SpawningFaction, ThreatLevel, and TimeRemaining are only
+// present for unidentified signal sources, and TimeRemaining is the number of
+// seconds until the signal expires.
+type FSSSignal struct {
+	IsStation       bool     `json:"IsStation,omitempty"`       // Optional
+	SignalName      string   `json:"SignalName"`                // Required
+	SignalType      string   `json:"SignalType,omitempty"`      // Optional
+	SpawningFaction string   `json:"SpawningFaction,omitempty"` // Optional
+	SpawningState   string   `json:"SpawningState,omitempty"`   // Optional
+	ThreatLevel     int      `json:"ThreatLevel,omitempty"`     // Optional
+	TimeRemaining   *float64 `json:"TimeRemaining,omitempty"`   // Optional
+	Timestamp       string   `json:"timestamp"`                 // Required
+	USSType         string   `json:"USSType,omitempty"`         // Optional
+}
+
+// FSSSignalDiscoveredMessage contains the signals found in a system by the
+// full spectrum scanner sent to EDDN.
+type FSSSignalDiscoveredMessage struct {
+	Event         string      `json:"event"`         // Required
+	Signals       []FSSSignal `json:"signals"`       // Required
+	StarPos       StarPos     `json:"StarPos"`       // Required
+	StarSystem    string      `json:"StarSystem"`    // Required
+	SystemAddress int64       `json:"SystemAddress"` // Required
+	Timestamp     string      `json:"timestamp"`     // Required
+}
+
+// FSSSignalDiscovered is the high level type that contains the entire JSON
+// message.
+type FSSSignalDiscovered struct {
+	SchemaRef string                     `json:"$schemaRef"`
+	Header    Header                     `json:"header"`
+	Message   FSSSignalDiscoveredMessage `json:"message"`
+}
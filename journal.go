@@ -1,5 +1,9 @@
 package EDDNClient
 
+import (
+	"time"
+)
+
 // Ring describes planetary rings of a body that may or may not be included
 // in a journal message.
 type Ring struct {
@@ -18,9 +22,13 @@ type Composition struct {
 }
 
 // Material describes the name, and percentage contained on a planet, or moon.
+// Name is the game's internal name, i.e. "iron", and should be used when
+// matching materials.  NameLocalised is only present when the uploader kept
+// the "Name_Localised" field, and depends on the language of the game.
 type Material struct {
-	Name    string  `mapstructure:"Name" json:"Name"`
-	Percent float64 `mapstructure:"Percent" json:"Percent"`
+	Name          string  `mapstructure:"Name" json:"Name"`
+	NameLocalised string  `mapstructure:"Name_Localised" json:"Name_Localised,omitempty"`
+	Percent       float64 `mapstructure:"Percent" json:"Percent"`
 }
 
 // Faction describes an individual faction that may or may not be included
@@ -33,53 +41,141 @@ type Faction struct {
 	Name         string  `mapstructure:"Name" json:"Name"`
 }
 
+// Signal describes the type, and number of signals found on a body.  Type
+// is the game's internal name, i.e. "$SAA_SignalType_Biological;", and
+// TypeLocalised is only present when the uploader kept it.
+type Signal struct {
+	Count         int    `mapstructure:"Count" json:"Count"`
+	Type          string `mapstructure:"Type" json:"Type"`
+	TypeLocalised string `mapstructure:"Type_Localised" json:"Type_Localised,omitempty"`
+}
+
+// Genus describes a genus of biological life found on a body.  Genus is the
+// game's internal name, i.e. "$Codex_Ent_Bacterial_Genus_Name;", and
+// GenusLocalised is only present when the uploader kept it.
+type Genus struct {
+	Genus          string `mapstructure:"Genus" json:"Genus"`
+	GenusLocalised string `mapstructure:"Genus_Localised" json:"Genus_Localised,omitempty"`
+}
+
+// JournalEconomy describes one of the economies of a station, and its
+// proportion of the station's market.  Name is the game's internal name,
+// i.e. "$economy_Industrial;", and NameLocalised is only present when the
+// uploader kept it.
+type JournalEconomy struct {
+	Name          string  `mapstructure:"Name" json:"Name"`
+	NameLocalised string  `mapstructure:"Name_Localised" json:"Name_Localised,omitempty"`
+	Proportion    float64 `mapstructure:"Proportion" json:"Proportion"`
+}
+
+// JournalSystem contains the system name, and coordinates of a journal
+// event.  It's embedded in CommonJournal.
+type JournalSystem struct {
+	StarPos       StarPos `mapstructure:"StarPos" json:"StarPos"`
+	StarSystem    string  `mapstructure:"StarSystem" json:"StarSystem"`
+	SystemAddress int64   `mapstructure:"SystemAddress" json:"SystemAddress,omitempty"`
+}
+
+// CommonJournal contains the fields shared by every journal event.  It's
+// embedded in each of the event types so the fields can be accessed directly,
+// and System can be used to find the system of any event.
+type CommonJournal struct {
+	JournalSystem `mapstructure:",squash"`
+	Event         string `mapstructure:"event" json:"event"`
+	Timestamp     string `mapstructure:"timestamp" json:"timestamp"`
+}
+
+// JournalEvent is implemented by every journal event type through
+// CommonJournal, which allows the receiver to handle any event without
+// asserting its concrete type.
+type JournalEvent interface {
+	EventName() string             // Name of the event.  i.e. "FSDJump"
+	EventTime() (time.Time, error) // Time the event took place
+	System() string                // System the event took place in
+}
+
+// EventName returns the name of the event.
+func (c CommonJournal) EventName() string {
+	return c.Event
+}
+
+// EventTime parses the Timestamp of the event.
+func (c CommonJournal) EventTime() (time.Time, error) {
+	return parseTimestamp(c.Timestamp)
+}
+
+// System returns the name of the system the event took place in.
+func (c CommonJournal) System() string {
+	return c.StarSystem
+}
+
 // JournalDocked contains information pertaining to a 'docked' event.  This
 // is missing the 'Security' field, but it seems to mostly go unused with this
-// event so it's omitted for now.
+// event so it's omitted for now.  StationEconomy is the station's primary
+// economy, and StationEconomies every economy with its proportion.
 type JournalDocked struct {
-	StarSystem        string    `mapstructure:"StarSystem" json:"StarSystem"`
-	StationFaction    string    `mapstructure:"StationFaction" json:"StationFaction"`
-	StationGovernment string    `mapstructure:"StationGovernment" json:"StationGovernment"`
-	Timestamp         string    `mapstructure:"timestamp" json:"timestamp"`
-	StationAllegiance string    `mapstructure:"StationAllegiance" json:"StationAllegiance"`
-	StationEconomy    string    `mapstructure:"StationEconomy" json:"StationEconomy"`
-	StarPos           []float64 `mapstructure:"StarPos" json:"StarPos"`
-	StationName       string    `mapstructure:"StationName" json:"StationName"`
-	StationType       string    `mapstructure:"StationType" json:"StationType"`
-	DistFromStarLS    float64   `mapstructure:"DistFromStarLS" json:"DistFromStarLS"`
-	FactionState      string    `mapstructure:"FactionState" json:"FactionState"`
-	Event             string    `mapstructure:"event" json:"event"`
+	CommonJournal     `mapstructure:",squash"`
+	StationFaction    string           `mapstructure:"StationFaction" json:"StationFaction"`
+	StationGovernment string           `mapstructure:"StationGovernment" json:"StationGovernment"`
+	StationAllegiance string           `mapstructure:"StationAllegiance" json:"StationAllegiance"`
+	StationEconomy    string           `mapstructure:"StationEconomy" json:"StationEconomy"`
+	StationEconomies  []JournalEconomy `mapstructure:"StationEconomies" json:"StationEconomies,omitempty"`
+	StationServices   []string         `mapstructure:"StationServices" json:"StationServices,omitempty"`
+	StationName       string           `mapstructure:"StationName" json:"StationName"`
+	StationType       string           `mapstructure:"StationType" json:"StationType"`
+	DistFromStarLS    float64          `mapstructure:"DistFromStarLS" json:"DistFromStarLS"`
+	FactionState      string           `mapstructure:"FactionState" json:"FactionState"`
+}
+
+// ScanType is how a body was scanned, which decides how much of a Scan event
+// is filled in.  Autoscans, and basic scans omit most of the body's
+// composition, so detailed scans should be preferred when they're available.
+type ScanType string
+
+// The scan types sent by the game.
+const (
+	ScanAutoScan        ScanType = "AutoScan"        // Scanned on arrival in the system
+	ScanBasic           ScanType = "Basic"           // Scanned from a distance
+	ScanDetailed        ScanType = "Detailed"        // Scanned with the FSS, or a detailed scan
+	ScanNavBeaconDetail ScanType = "NavBeaconDetail" // Received from a nav beacon
+)
+
+// Detailed reports whether t is a scan that includes every detail of the
+// body, which autoscans, and basic scans don't.
+func (t ScanType) Detailed() bool {
+	return t == ScanDetailed || t == ScanNavBeaconDetail
 }
 
 // JournalScanStar contains information about a scanned star.  This is used
 // when a journal entry has a StarType field.  Barring that a JournalScanPlanet
 // type will be used.
 type JournalScanStar struct {
-	StellarMass           float64   `mapstructure:"StellarMass" json:"StellarMass"`
-	BodyName              string    `mapstructure:"BodyName" json:"BodyName"`
-	StarSystem            string    `mapstructure:"StarSystem" json:"StarSystem"`
-	Timestamp             string    `mapstructure:"timestamp" json:"timestamp"`
-	RotationPeriod        float64   `mapstructure:"RotationPeriod" json:"RotationPeriod"`
-	Rings                 []Ring    `mapstructure:"Rings" json:"Rings"`
-	StarType              string    `mapstructure:"StarType" json:"StarType"`
-	Radius                float64   `mapstructure:"Radius" json:"Radius"`
-	AbsoluteMagnitude     float64   `mapstructure:"AbsoluteMagnitude" json:"AbsoluteMagnitude"`
-	StarPos               []float64 `mapstructure:"StarPos" json:"StarPos"`
-	AgeMy                 int       `mapstructure:"Age_MY" json:"Age_MY"`
-	Event                 string    `mapstructure:"event" json:"event"`
-	DistanceFromArrivalLS float64   `mapstructure:"DistanceFromArrivalLS" json:"DistanceFromArrivalLS"`
-	SurfaceTemperature    float64   `mapstructure:"SurfaceTemperature" json:"SurfaceTemperature"`
-	Eccentricity          float64   `mapstructure:"Eccentricity" json:"Eccentricity"`
-	OrbitalInclination    float64   `mapstructure:"OrbitalInclination" json:"OrbitalInclination"`
-	OrbitalPeriod         float64   `mapstructure:"OrbitalPeriod" json:"OrbitalPeriod"`
-	Periapsis             float64   `mapstructure:"Periapsis" json:"Periapsis"`
-	SemiMajorAxis         float64   `mapstructure:"SemiMajorAxis" json:"SemiMajorAxis"`
+	CommonJournal         `mapstructure:",squash"`
+	ScanType              ScanType `mapstructure:"ScanType" json:"ScanType"`
+	StellarMass           float64  `mapstructure:"StellarMass" json:"StellarMass"`
+	BodyName              string   `mapstructure:"BodyName" json:"BodyName"`
+	RotationPeriod        float64  `mapstructure:"RotationPeriod" json:"RotationPeriod"`
+	Rings                 []Ring   `mapstructure:"Rings" json:"Rings"`
+	StarType              string   `mapstructure:"StarType" json:"StarType"`
+	Radius                float64  `mapstructure:"Radius" json:"Radius"`
+	AbsoluteMagnitude     float64  `mapstructure:"AbsoluteMagnitude" json:"AbsoluteMagnitude"`
+	AgeMy                 int      `mapstructure:"Age_MY" json:"Age_MY"`
+	DistanceFromArrivalLS float64  `mapstructure:"DistanceFromArrivalLS" json:"DistanceFromArrivalLS"`
+	SurfaceTemperature    float64  `mapstructure:"SurfaceTemperature" json:"SurfaceTemperature"`
+	Eccentricity          float64  `mapstructure:"Eccentricity" json:"Eccentricity"`
+	OrbitalInclination    float64  `mapstructure:"OrbitalInclination" json:"OrbitalInclination"`
+	OrbitalPeriod         float64  `mapstructure:"OrbitalPeriod" json:"OrbitalPeriod"`
+	Periapsis             float64  `mapstructure:"Periapsis" json:"Periapsis"`
+	SemiMajorAxis         float64  `mapstructure:"SemiMajorAxis" json:"SemiMajorAxis"`
 }
 
 // JournalScanPlanet contains information about a scanned moon, or planet.
-// This is used when a journal entry does NOT have a StarType field.  If it
-// does then a JournalScanStar type will be used.
+// This is used when a journal entry does NOT have a StarType field, and isn't
+// a belt cluster, or ring.  Entries with a StarType field use the
+// JournalScanStar type instead.
 type JournalScanPlanet struct {
+	CommonJournal         `mapstructure:",squash"`
+	ScanType              ScanType   `mapstructure:"ScanType" json:"ScanType"`
 	Eccentricity          float64    `mapstructure:"Eccentricity" json:"Eccentricity"`
 	OrbitalInclination    float64    `mapstructure:"OrbitalInclination" json:"OrbitalInclination"`
 	OrbitalPeriod         float64    `mapstructure:"OrbitalPeriod" json:"OrbitalPeriod"`
@@ -93,31 +189,57 @@ type JournalScanPlanet struct {
 	SurfacePressure       float64    `mapstructure:"SurfacePressure" json:"SurfacePressure"`
 	MassEM                float64    `mapstructure:"MassEM" json:"MassEM"`
 	RotationPeriod        float64    `mapstructure:"RotationPeriod" json:"RotationPeriod"`
-	Event                 string     `mapstructure:"event" json:"event"`
-	StarPos               []float64  `mapstructure:"StarPos" json:"StarPos"`
 	AtmosphereType        string     `mapstructure:"AtmosphereType" json:"AtmosphereType"`
 	SurfaceTemperature    float64    `mapstructure:"SurfaceTemperature" json:"SurfaceTemperature"`
-	Timestamp             string     `mapstructure:"timestamp" json:"timestamp"`
 	Materials             []Material `mapstructure:"Materials" json:"Materials"`
 	Volcanism             string     `mapstructure:"Volcanism" json:"Volcanism"`
-	StarSystem            string     `mapstructure:"StarSystem" json:"StarSystem"`
 	Atmosphere            string     `mapstructure:"Atmosphere" json:"Atmosphere"`
 	Landable              bool       `mapstructure:"Landable" json:"Landable"`
 	Radius                float64    `mapstructure:"Radius" json:"Radius"`
 	SurfaceGravity        float64    `mapstructure:"SurfaceGravity" json:"SurfaceGravity"`
+	Rings                 []Ring     `mapstructure:"Rings" json:"Rings"`
+	ReserveLevel          string     `mapstructure:"ReserveLevel" json:"ReserveLevel"`
+}
+
+// MaterialPercentages returns the Materials of the planet as a map of each
+// material's name to its percentage.
+func (planet JournalScanPlanet) MaterialPercentages() map[string]float64 {
+	materials := make(map[string]float64, len(planet.Materials))
+
+	for _, material := range planet.Materials {
+		materials[material.Name] = material.Percent
+	}
+
+	return materials
+}
+
+// JournalScanBeltCluster contains information about a scanned asteroid belt
+// cluster.  This is used when a journal entry has neither a StarType, nor a
+// PlanetClass field, and the BodyName contains "Belt Cluster".
+type JournalScanBeltCluster struct {
+	CommonJournal         `mapstructure:",squash"`
+	ScanType              ScanType `mapstructure:"ScanType" json:"ScanType"`
+	BodyID                int      `mapstructure:"BodyID" json:"BodyID"`
+	BodyName              string   `mapstructure:"BodyName" json:"BodyName"`
+	DistanceFromArrivalLS float64  `mapstructure:"DistanceFromArrivalLS" json:"DistanceFromArrivalLS"`
 }
 
+// JournalScanRing contains information about a scanned planetary, or stellar
+// ring.  This is used when a journal entry has neither a StarType, nor a
+// PlanetClass field, and the BodyName ends with "Ring".  The ring's mass, and
+// radii are found in the Rings of the body it belongs to.  The event has the
+// same fields as a JournalScanBeltCluster, but is a distinct type so the two
+// may be told apart.
+type JournalScanRing JournalScanBeltCluster
+
 // JournalFSDJump contains information about a system after a frameshift
 // jump is performed.
 type JournalFSDJump struct {
-	StarSystem       string    `mapstructure:"StarSystem" json:"StarSystem"`
-	Timestamp        string    `mapstructure:"timestamp" json:"timestamp"`
-	Event            string    `mapstructure:"event" json:"event"`
-	SystemSecurity   string    `mapstructure:"SystemSecurity" json:"SystemSecurity"`
-	SystemAllegiance string    `mapstructure:"SystemAllegiance" json:"SystemAllegiance"`
-	SystemEconomy    string    `mapstructure:"SystemEconomy" json:"SystemEconomy"`
-	StarPos          []float64 `mapstructure:"StarPos" json:"StarPos"`
-	SystemGovernment string    `mapstructure:"SystemGovernment" json:"SystemGovernment"`
+	CommonJournal    `mapstructure:",squash"`
+	SystemSecurity   string `mapstructure:"SystemSecurity" json:"SystemSecurity"`
+	SystemAllegiance string `mapstructure:"SystemAllegiance" json:"SystemAllegiance"`
+	SystemEconomy    string `mapstructure:"SystemEconomy" json:"SystemEconomy"`
+	SystemGovernment string `mapstructure:"SystemGovernment" json:"SystemGovernment"`
 }
 
 // Journal is the high level type that contains the entire JSON message.
@@ -126,3 +248,103 @@ type Journal struct {
 	Header    Header      `json:"header"`
 	Message   interface{} `json:"message"`
 }
+
+// Event returns the Message of j as a JournalEvent.  ok is false if the
+// Message isn't a JournalEvent, which may be the case for events decoded by
+// a JournalEventDecoder registered by the receiver.
+func (j Journal) Event() (event JournalEvent, ok bool) {
+	event, ok = j.Message.(JournalEvent)
+	return event, ok
+}
+
+// JournalFSSDiscoveryScan contains the results of a full spectrum scanner
+// discovery scan (honk) of a system.
+type JournalFSSDiscoveryScan struct {
+	CommonJournal `mapstructure:",squash"`
+	BodyCount     int     `mapstructure:"BodyCount" json:"BodyCount"`
+	NonBodyCount  int     `mapstructure:"NonBodyCount" json:"NonBodyCount"`
+	Progress      float64 `mapstructure:"Progress" json:"Progress"`
+	SystemName    string  `mapstructure:"SystemName" json:"SystemName"`
+}
+
+// JournalSAASignalsFound contains the signals found on a body after it has
+// been mapped by a detailed surface scanner.  Genuses is only included by
+// newer clients so it may be empty.
+type JournalSAASignalsFound struct {
+	CommonJournal `mapstructure:",squash"`
+	BodyID        int      `mapstructure:"BodyID" json:"BodyID"`
+	BodyName      string   `mapstructure:"BodyName" json:"BodyName"`
+	Genuses       []Genus  `mapstructure:"Genuses" json:"Genuses,omitempty"`
+	Signals       []Signal `mapstructure:"Signals" json:"Signals"`
+}
+
+// JournalCarrierJump contains information about a system after a fleet
+// carrier the commander is docked at has jumped.  It carries both the system
+// information of a JournalFSDJump, and the station information of a
+// JournalDocked.
+type JournalCarrierJump struct {
+	CommonJournal     `mapstructure:",squash"`
+	Body              string    `mapstructure:"Body" json:"Body,omitempty"`
+	BodyID            int       `mapstructure:"BodyID" json:"BodyID,omitempty"`
+	BodyType          string    `mapstructure:"BodyType" json:"BodyType,omitempty"`
+	Docked            bool      `mapstructure:"Docked" json:"Docked"`
+	Factions          []Faction `mapstructure:"Factions" json:"Factions,omitempty"`
+	MarketID          int64     `mapstructure:"MarketID" json:"MarketID"`
+	Population        int64     `mapstructure:"Population" json:"Population"`
+	StationEconomy    string    `mapstructure:"StationEconomy" json:"StationEconomy"`
+	StationGovernment string    `mapstructure:"StationGovernment" json:"StationGovernment"`
+	StationName       string    `mapstructure:"StationName" json:"StationName"`
+	StationType       string    `mapstructure:"StationType" json:"StationType"`
+	SystemAllegiance  string    `mapstructure:"SystemAllegiance" json:"SystemAllegiance"`
+	SystemEconomy     string    `mapstructure:"SystemEconomy" json:"SystemEconomy"`
+	SystemGovernment  string    `mapstructure:"SystemGovernment" json:"SystemGovernment"`
+	SystemSecurity    string    `mapstructure:"SystemSecurity" json:"SystemSecurity"`
+}
+
+// JournalLocation contains the location of the commander when the game is
+// started, or after certain transitions such as respawning.  The station
+// fields are only present when Docked is true, and Body, BodyID, and
+// BodyType are only present when the commander is near a body.
+type JournalLocation struct {
+	CommonJournal     `mapstructure:",squash"`
+	Body              string    `mapstructure:"Body" json:"Body,omitempty"`
+	BodyID            int       `mapstructure:"BodyID" json:"BodyID,omitempty"`
+	BodyType          string    `mapstructure:"BodyType" json:"BodyType,omitempty"`
+	DistFromStarLS    float64   `mapstructure:"DistFromStarLS" json:"DistFromStarLS,omitempty"`
+	Docked            bool      `mapstructure:"Docked" json:"Docked"`
+	Factions          []Faction `mapstructure:"Factions" json:"Factions,omitempty"`
+	MarketID          int64     `mapstructure:"MarketID" json:"MarketID,omitempty"`
+	Population        int64     `mapstructure:"Population" json:"Population"`
+	StationEconomy    string    `mapstructure:"StationEconomy" json:"StationEconomy,omitempty"`
+	StationGovernment string    `mapstructure:"StationGovernment" json:"StationGovernment,omitempty"`
+	StationName       string    `mapstructure:"StationName" json:"StationName,omitempty"`
+	StationType       string    `mapstructure:"StationType" json:"StationType,omitempty"`
+	SystemAllegiance  string    `mapstructure:"SystemAllegiance" json:"SystemAllegiance"`
+	SystemEconomy     string    `mapstructure:"SystemEconomy" json:"SystemEconomy"`
+	SystemGovernment  string    `mapstructure:"SystemGovernment" json:"SystemGovernment"`
+	SystemSecurity    string    `mapstructure:"SystemSecurity" json:"SystemSecurity"`
+}
+
+// JournalNavBeaconScan contains the number of bodies discovered after
+// scanning the nav beacon of a system.
+type JournalNavBeaconScan struct {
+	CommonJournal `mapstructure:",squash"`
+	NumBodies     int `mapstructure:"NumBodies" json:"NumBodies"`
+}
+
+// JournalUndocked contains the station a commander has left.  Taxi, and
+// Multicrew are only sent by newer clients, and are nil when they weren't
+// sent.
+type JournalUndocked struct {
+	CommonJournal `mapstructure:",squash"`
+	MarketID      int64  `mapstructure:"MarketID" json:"MarketID"`
+	Multicrew     *bool  `mapstructure:"Multicrew" json:"Multicrew,omitempty"`
+	StationName   string `mapstructure:"StationName" json:"StationName"`
+	StationType   string `mapstructure:"StationType" json:"StationType"`
+	Taxi          *bool  `mapstructure:"Taxi" json:"Taxi,omitempty"`
+}
+
+// FleetCarrier reports whether the station undocked from is a fleet carrier.
+func (undocked JournalUndocked) FleetCarrier() bool {
+	return ParseStationType(undocked.StationType) == StationTypeFleetCarrier
+}
@@ -0,0 +1,958 @@
+package EDDNClient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Every journal event type must implement JournalEvent.
+var (
+	_ JournalEvent = JournalDocked{}
+	_ JournalEvent = JournalScanStar{}
+	_ JournalEvent = JournalScanPlanet{}
+	_ JournalEvent = JournalScanBeltCluster{}
+	_ JournalEvent = JournalScanRing{}
+	_ JournalEvent = JournalFSDJump{}
+	_ JournalEvent = JournalFSSDiscoveryScan{}
+	_ JournalEvent = JournalSAASignalsFound{}
+	_ JournalEvent = JournalCarrierJump{}
+	_ JournalEvent = JournalLocation{}
+	_ JournalEvent = JournalNavBeaconScan{}
+	_ JournalEvent = JournalUndocked{}
+)
+
+// journalFixture wraps a journal event in a journal/1 message.
+func journalFixture(event string) string {
+	return `{
+		"$schemaRef": "https://eddn.edcd.io/schemas/journal/1",
+		"header": {
+			"uploaderID": "abcdef0123456789",
+			"softwareName": "E:D Market Connector [Windows]",
+			"softwareVersion": "5.1.1"
+		},
+		"message": ` + event + `
+	}`
+}
+
+// parseJournalFixture parses event as a journal message and returns the
+// decoded journal event.
+func parseJournalFixture(t *testing.T, event string) interface{} {
+	result, err := parseJSON(journalFixture(event))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	journal, ok := result.Message.(Journal)
+
+	if !ok {
+		t.Fatalf("Expected Journal, got %T", result.Message)
+	}
+
+	return journal.Message
+}
+
+func TestParseJournalFSSDiscoveryScan(t *testing.T) {
+	msg := parseJournalFixture(t, `{
+		"timestamp": "2021-05-25T18:06:08Z",
+		"event": "FSSDiscoveryScan",
+		"Progress": 0.436366,
+		"BodyCount": 23,
+		"NonBodyCount": 40,
+		"SystemName": "Pleione",
+		"SystemAddress": 2862335682961,
+		"StarSystem": "Pleione",
+		"StarPos": [-77.0, -146.78125, -344.125]
+	}`)
+
+	scan, ok := msg.(JournalFSSDiscoveryScan)
+
+	if !ok {
+		t.Fatalf("Expected JournalFSSDiscoveryScan, got %T", msg)
+	}
+
+	if scan.BodyCount != 23 || scan.NonBodyCount != 40 ||
+		scan.Progress != 0.436366 || scan.SystemName != "Pleione" ||
+		scan.SystemAddress != 2862335682961 || scan.StarSystem != "Pleione" ||
+		scan.Timestamp != "2021-05-25T18:06:08Z" {
+		t.Errorf("Unexpected scan: %+v", scan)
+	}
+
+	if scan.StarPos != (StarPos{-77.0, -146.78125, -344.125}) {
+		t.Errorf("Unexpected StarPos: %v", scan.StarPos)
+	}
+}
+
+func TestParseJournalSAASignalsFound(t *testing.T) {
+	msg := parseJournalFixture(t, `{
+		"timestamp": "2021-05-25T18:31:16Z",
+		"event": "SAASignalsFound",
+		"BodyName": "Pleione 3 a",
+		"SystemAddress": 2862335682961,
+		"BodyID": 12,
+		"Signals": [
+			{"Type": "$SAA_SignalType_Biological;",
+				"Type_Localised": "Biological", "Count": 3},
+			{"Type": "$SAA_SignalType_Geological;", "Count": 5}
+		],
+		"Genuses": [
+			{"Genus": "$Codex_Ent_Bacterial_Genus_Name;",
+				"Genus_Localised": "Bacterium"},
+			{"Genus": "$Codex_Ent_Stratum_Genus_Name;"}
+		],
+		"StarSystem": "Pleione",
+		"StarPos": [-77.0, -146.78125, -344.125]
+	}`)
+
+	signals, ok := msg.(JournalSAASignalsFound)
+
+	if !ok {
+		t.Fatalf("Expected JournalSAASignalsFound, got %T", msg)
+	}
+
+	if signals.BodyName != "Pleione 3 a" || signals.BodyID != 12 {
+		t.Errorf("Unexpected body: %+v", signals)
+	}
+
+	if len(signals.Signals) != 2 ||
+		signals.Signals[0].Type != "$SAA_SignalType_Biological;" ||
+		signals.Signals[0].Count != 3 || signals.Signals[1].Count != 5 {
+		t.Errorf("Unexpected signals: %+v", signals.Signals)
+	}
+
+	if len(signals.Genuses) != 2 ||
+		signals.Genuses[1].Genus != "$Codex_Ent_Stratum_Genus_Name;" {
+		t.Errorf("Unexpected genuses: %+v", signals.Genuses)
+	}
+
+	// The internal names are kept apart from the localised ones.
+	if signals.Signals[0].TypeLocalised != "Biological" ||
+		signals.Signals[1].TypeLocalised != "" ||
+		signals.Genuses[0].Genus != "$Codex_Ent_Bacterial_Genus_Name;" ||
+		signals.Genuses[0].GenusLocalised != "Bacterium" {
+		t.Errorf("Unexpected localised names: %+v", signals)
+	}
+}
+
+func TestParseJournalSAASignalsFoundNoGenuses(t *testing.T) {
+	msg := parseJournalFixture(t, `{
+		"timestamp": "2019-01-01T00:00:00Z",
+		"event": "SAASignalsFound",
+		"BodyName": "Pleione 3 a",
+		"SystemAddress": 2862335682961,
+		"BodyID": 12,
+		"Signals": [{"Type": "LowTemperatureDiamond", "Count": 1}],
+		"StarSystem": "Pleione",
+		"StarPos": [-77.0, -146.78125, -344.125]
+	}`)
+
+	signals, ok := msg.(JournalSAASignalsFound)
+
+	if !ok {
+		t.Fatalf("Expected JournalSAASignalsFound, got %T", msg)
+	}
+
+	if len(signals.Genuses) != 0 || len(signals.Signals) != 1 {
+		t.Errorf("Unexpected signals: %+v", signals)
+	}
+}
+
+func TestParseJournalFSDJump(t *testing.T) {
+	msg := parseJournalFixture(t, `{
+		"timestamp": "2017-01-01T12:00:00Z",
+		"event": "FSDJump",
+		"StarSystem": "Pleione",
+		"SystemAddress": 2862335682961,
+		"StarPos": [-77.0, -146.78125, -344.125],
+		"SystemAllegiance": "Federation",
+		"SystemEconomy": "$economy_Industrial;",
+		"SystemGovernment": "$government_Democracy;",
+		"SystemSecurity": "$SYSTEM_SECURITY_high;"
+	}`)
+
+	jump, ok := msg.(JournalFSDJump)
+
+	if !ok {
+		t.Fatalf("Expected JournalFSDJump, got %T", msg)
+	}
+
+	if jump.StarSystem != "Pleione" || jump.SystemAddress != 2862335682961 ||
+		jump.StarPos.Z != -344.125 || jump.SystemAllegiance != "Federation" {
+		t.Errorf("Unexpected jump: %+v", jump)
+	}
+}
+
+func TestParseJournalCarrierJump(t *testing.T) {
+	msg := parseJournalFixture(t, `{
+		"timestamp": "2020-06-10T19:14:37Z",
+		"event": "CarrierJump",
+		"Docked": true,
+		"StationName": "Q2K-BHB",
+		"StationType": "FleetCarrier",
+		"MarketID": 3700005632,
+		"StationGovernment": "$government_Carrier;",
+		"StationEconomy": "$economy_Carrier;",
+		"StarSystem": "Mirnes",
+		"SystemAddress": 3107509474002,
+		"StarPos": [-1.53125, 36.90625, -20.6875],
+		"SystemAllegiance": "Independent",
+		"SystemEconomy": "$economy_Extraction;",
+		"SystemGovernment": "$government_Cooperative;",
+		"SystemSecurity": "$SYSTEM_SECURITY_medium;",
+		"Population": 340008,
+		"Body": "Mirnes A 2",
+		"BodyID": 9,
+		"BodyType": "Planet",
+		"Factions": [
+			{
+				"Name": "Mirnes Jet Boys",
+				"FactionState": "None",
+				"Government": "Anarchy",
+				"Influence": 0.0731,
+				"Allegiance": "Independent"
+			}
+		]
+	}`)
+
+	jump, ok := msg.(JournalCarrierJump)
+
+	if !ok {
+		t.Fatalf("Expected JournalCarrierJump, got %T", msg)
+	}
+
+	if jump.StarSystem != "Mirnes" || jump.SystemAddress != 3107509474002 ||
+		jump.StarPos.X != -1.53125 {
+		t.Errorf("Unexpected system: %+v", jump.JournalSystem)
+	}
+
+	if !jump.Docked || jump.StationName != "Q2K-BHB" ||
+		jump.StationType != "FleetCarrier" || jump.MarketID != 3700005632 {
+		t.Errorf("Unexpected station: %+v", jump)
+	}
+
+	if jump.Population != 340008 || jump.Body != "Mirnes A 2" ||
+		jump.BodyID != 9 || jump.SystemSecurity != "$SYSTEM_SECURITY_medium;" {
+		t.Errorf("Unexpected details: %+v", jump)
+	}
+
+	if len(jump.Factions) != 1 || jump.Factions[0].Name != "Mirnes Jet Boys" {
+		t.Errorf("Unexpected factions: %+v", jump.Factions)
+	}
+}
+
+func TestParseJournalLocationDocked(t *testing.T) {
+	msg := parseJournalFixture(t, `{
+		"timestamp": "2021-05-25T17:45:02Z",
+		"event": "Location",
+		"Docked": true,
+		"StationName": "Stargazer",
+		"StationType": "Coriolis",
+		"MarketID": 128016640,
+		"StationGovernment": "$government_Cooperative;",
+		"StationEconomy": "$economy_Tourism;",
+		"DistFromStarLS": 183.4,
+		"StarSystem": "Pleione",
+		"SystemAddress": 2862335682961,
+		"StarPos": [-77.0, -146.78125, -344.125],
+		"SystemAllegiance": "Independent",
+		"SystemEconomy": "$economy_Tourism;",
+		"SystemGovernment": "$government_Cooperative;",
+		"SystemSecurity": "$SYSTEM_SECURITY_medium;",
+		"Population": 52057,
+		"Body": "Stargazer",
+		"BodyID": 31,
+		"BodyType": "Station"
+	}`)
+
+	location, ok := msg.(JournalLocation)
+
+	if !ok {
+		t.Fatalf("Expected JournalLocation, got %T", msg)
+	}
+
+	if !location.Docked || location.StationName != "Stargazer" ||
+		location.StationType != "Coriolis" || location.MarketID != 128016640 {
+		t.Errorf("Unexpected station: %+v", location)
+	}
+
+	if location.Body != "Stargazer" || location.BodyType != "Station" ||
+		location.BodyID != 31 || location.StarSystem != "Pleione" {
+		t.Errorf("Unexpected body: %+v", location)
+	}
+}
+
+func TestParseJournalLocationUndocked(t *testing.T) {
+	msg := parseJournalFixture(t, `{
+		"timestamp": "2021-05-25T17:45:02Z",
+		"event": "Location",
+		"Docked": false,
+		"StarSystem": "Pleione",
+		"SystemAddress": 2862335682961,
+		"StarPos": [-77.0, -146.78125, -344.125],
+		"SystemAllegiance": "Independent",
+		"SystemEconomy": "$economy_Tourism;",
+		"SystemGovernment": "$government_Cooperative;",
+		"SystemSecurity": "$SYSTEM_SECURITY_medium;",
+		"Population": 52057
+	}`)
+
+	location, ok := msg.(JournalLocation)
+
+	if !ok {
+		t.Fatalf("Expected JournalLocation, got %T", msg)
+	}
+
+	if location.Docked || location.StationName != "" || location.Body != "" ||
+		location.BodyType != "" || location.MarketID != 0 {
+		t.Errorf("Unexpected station, or body: %+v", location)
+	}
+
+	if location.Population != 52057 || location.StarPos.Y != -146.78125 {
+		t.Errorf("Unexpected system: %+v", location)
+	}
+}
+
+func TestParseJournalScanBeltCluster(t *testing.T) {
+	msg := parseJournalFixture(t, `{
+		"timestamp": "2021-05-25T18:10:11Z",
+		"event": "Scan",
+		"ScanType": "AutoScan",
+		"BodyName": "Pleione A Belt Cluster 4",
+		"BodyID": 7,
+		"StarSystem": "Pleione",
+		"SystemAddress": 2862335682961,
+		"StarPos": [-77.0, -146.78125, -344.125],
+		"DistanceFromArrivalLS": 1240.5
+	}`)
+
+	belt, ok := msg.(JournalScanBeltCluster)
+
+	if !ok {
+		t.Fatalf("Expected JournalScanBeltCluster, got %T", msg)
+	}
+
+	if belt.BodyName != "Pleione A Belt Cluster 4" || belt.BodyID != 7 ||
+		belt.DistanceFromArrivalLS != 1240.5 || belt.StarSystem != "Pleione" {
+		t.Errorf("Unexpected belt cluster: %+v", belt)
+	}
+
+	if belt.ScanType != ScanAutoScan || belt.ScanType.Detailed() {
+		t.Errorf("Unexpected scan type: %q", belt.ScanType)
+	}
+}
+
+func TestParseJournalScanRing(t *testing.T) {
+	msg := parseJournalFixture(t, `{
+		"timestamp": "2021-05-25T18:12:44Z",
+		"event": "Scan",
+		"ScanType": "Detailed",
+		"BodyName": "Pleione 3 A Ring",
+		"BodyID": 13,
+		"StarSystem": "Pleione",
+		"SystemAddress": 2862335682961,
+		"StarPos": [-77.0, -146.78125, -344.125],
+		"DistanceFromArrivalLS": 843.2
+	}`)
+
+	ring, ok := msg.(JournalScanRing)
+
+	if !ok {
+		t.Fatalf("Expected JournalScanRing, got %T", msg)
+	}
+
+	if ring.BodyName != "Pleione 3 A Ring" || ring.BodyID != 13 {
+		t.Errorf("Unexpected ring: %+v", ring)
+	}
+
+	if ring.ScanType != ScanDetailed || !ring.ScanType.Detailed() {
+		t.Errorf("Unexpected scan type: %q", ring.ScanType)
+	}
+}
+
+func TestParseJournalScanPlanet(t *testing.T) {
+	msg := parseJournalFixture(t, `{
+		"timestamp": "2021-05-25T18:12:44Z",
+		"event": "Scan",
+		"ScanType": "Detailed",
+		"BodyName": "Pleione 3",
+		"BodyID": 12,
+		"StarSystem": "Pleione",
+		"StarPos": [-77.0, -146.78125, -344.125],
+		"DistanceFromArrivalLS": 843.2,
+		"PlanetClass": "Gas giant with water based life",
+		"Landable": false
+	}`)
+
+	planet, ok := msg.(JournalScanPlanet)
+
+	if !ok {
+		t.Fatalf("Expected JournalScanPlanet, got %T", msg)
+	}
+
+	if planet.BodyName != "Pleione 3" ||
+		planet.PlanetClass != "Gas giant with water based life" {
+		t.Errorf("Unexpected planet: %+v", planet)
+	}
+}
+
+func TestParseJournalScanPlanetRingsMaterials(t *testing.T) {
+	msg := parseJournalFixture(t, `{
+		"timestamp": "2021-05-25T18:14:02Z",
+		"event": "Scan",
+		"ScanType": "Detailed",
+		"BodyName": "Pleione 5",
+		"BodyID": 15,
+		"StarSystem": "Pleione",
+		"StarPos": [-77.0, -146.78125, -344.125],
+		"DistanceFromArrivalLS": 1520.7,
+		"PlanetClass": "Icy body",
+		"Landable": true,
+		"Materials": [
+			{"Name": "iron", "Percent": 19.5},
+			{"Name": "nickel", "Percent": 14.75},
+			{"Name": "polonium", "Name_Localised": "Polonium", "Percent": 0.9}
+		],
+		"Rings": [
+			{
+				"Name": "Pleione 5 A Ring",
+				"RingClass": "eRingClass_Icy",
+				"MassMT": 3.1065e+10,
+				"InnerRad": 4.3266e+07,
+				"OuterRad": 8.6217e+07
+			}
+		],
+		"ReserveLevel": "PristineResources"
+	}`)
+
+	planet, ok := msg.(JournalScanPlanet)
+
+	if !ok {
+		t.Fatalf("Expected JournalScanPlanet, got %T", msg)
+	}
+
+	if len(planet.Rings) != 1 {
+		t.Fatalf("Expected 1 ring, got %d", len(planet.Rings))
+	}
+
+	ring := planet.Rings[0]
+
+	if ring.Name != "Pleione 5 A Ring" || ring.RingClass != "eRingClass_Icy" ||
+		ring.MassMT != 3.1065e+10 || ring.InnerRad != 4.3266e+07 ||
+		ring.OuterRad != 8.6217e+07 {
+		t.Errorf("Unexpected ring: %+v", ring)
+	}
+
+	materials := planet.MaterialPercentages()
+
+	if len(materials) != 3 || materials["iron"] != 19.5 ||
+		materials["polonium"] != 0.9 {
+		t.Errorf("Unexpected materials: %v", materials)
+	}
+
+	// MaterialPercentages is keyed by the internal name, not the localised one.
+	if polonium := planet.Materials[2]; polonium.Name != "polonium" ||
+		polonium.NameLocalised != "Polonium" || planet.Materials[0].NameLocalised != "" {
+		t.Errorf("Unexpected localised names: %+v", planet.Materials)
+	}
+
+	if planet.ReserveLevel != "PristineResources" {
+		t.Errorf("Unexpected reserve level: %s", planet.ReserveLevel)
+	}
+}
+
+func TestParseJournalNavBeaconScan(t *testing.T) {
+	msg := parseJournalFixture(t, `{
+		"timestamp": "2021-05-25T17:58:12Z",
+		"event": "NavBeaconScan",
+		"SystemAddress": 2862335682961,
+		"NumBodies": 23,
+		"StarSystem": "Pleione",
+		"StarPos": [-77.0, -146.78125, -344.125]
+	}`)
+
+	scan, ok := msg.(JournalNavBeaconScan)
+
+	if !ok {
+		t.Fatalf("Expected JournalNavBeaconScan, got %T", msg)
+	}
+
+	if scan.NumBodies != 23 || scan.SystemAddress != 2862335682961 ||
+		scan.StarSystem != "Pleione" || scan.Timestamp != "2021-05-25T17:58:12Z" {
+		t.Errorf("Unexpected scan: %+v", scan)
+	}
+}
+
+func TestParseJournalDockedServices(t *testing.T) {
+	msg := parseJournalFixture(t, `{
+		"timestamp": "2021-05-25T18:20:03Z",
+		"event": "Docked",
+		"StationName": "Jameson Memorial",
+		"StationType": "Orbis",
+		"StarSystem": "Shinrarta Dezhra",
+		"SystemAddress": 3932277478106,
+		"StarPos": [55.71875, 17.59375, 27.15625],
+		"StationGovernment": "$government_Democracy;",
+		"StationAllegiance": "PilotsFederation",
+		"StationEconomy": "$economy_HighTech;",
+		"StationEconomies": [
+			{"Name": "$economy_HighTech;", "Proportion": 0.8},
+			{"Name": "$economy_Industrial;", "Proportion": 0.2}
+		],
+		"StationServices": ["dock", "autodock", "blackmarket", "commodities",
+			"contacts", "exploration", "missions", "outfitting", "crewlounge",
+			"rearm", "refuel", "repair", "shipyard", "tuning", "engineer",
+			"missionsgenerated", "flightcontroller", "stationoperations",
+			"powerplay", "searchrescue", "materialtrader", "stationMenu",
+			"shop", "livery", "socialspace", "bartender", "vistagenomics",
+			"pioneersupplies", "apexinterstellar", "frontlinesolutions"],
+		"DistFromStarLS": 351.5
+	}`)
+
+	docked, ok := msg.(JournalDocked)
+
+	if !ok {
+		t.Fatalf("Expected JournalDocked, got %T", msg)
+	}
+
+	if docked.StationGovernment != "$government_Democracy;" ||
+		docked.StationEconomy != "$economy_HighTech;" {
+		t.Errorf("Unexpected station: %+v", docked)
+	}
+
+	expected := []JournalEconomy{
+		{Name: "$economy_HighTech;", Proportion: 0.8},
+		{Name: "$economy_Industrial;", Proportion: 0.2}}
+
+	if len(docked.StationEconomies) != 2 || docked.StationEconomies[0] != expected[0] ||
+		docked.StationEconomies[1] != expected[1] {
+		t.Errorf("Unexpected economies: %+v", docked.StationEconomies)
+	}
+
+	if len(docked.StationServices) != 30 || docked.StationServices[0] != "dock" ||
+		docked.StationServices[29] != "frontlinesolutions" {
+		t.Errorf("Unexpected services: %v", docked.StationServices)
+	}
+
+	// Economies are encoded with the journal's own field names.
+	encoded, err := json.Marshal(docked.StationEconomies[0])
+
+	if err != nil || string(encoded) != `{"Name":"$economy_HighTech;","Proportion":0.8}` {
+		t.Errorf("Unexpected encoded economy: %s, %v", encoded, err)
+	}
+}
+
+func TestParseJournalUndocked(t *testing.T) {
+	msg := parseJournalFixture(t, `{
+		"timestamp": "2021-05-25T18:10:42Z",
+		"event": "Undocked",
+		"StationName": "Trophy Camp",
+		"StationType": "Outpost",
+		"MarketID": 3229756160,
+		"Taxi": false,
+		"StarSystem": "Pleione",
+		"SystemAddress": 2862335682961,
+		"StarPos": [-77.0, -146.78125, -344.125]
+	}`)
+
+	undocked, ok := msg.(JournalUndocked)
+
+	if !ok {
+		t.Fatalf("Expected JournalUndocked, got %T", msg)
+	}
+
+	if undocked.StationName != "Trophy Camp" || undocked.StationType != "Outpost" ||
+		undocked.MarketID != 3229756160 || undocked.StarSystem != "Pleione" {
+		t.Errorf("Unexpected undocked: %+v", undocked)
+	}
+
+	if undocked.Taxi == nil || *undocked.Taxi || undocked.Multicrew != nil {
+		t.Errorf("Unexpected Taxi %v, and Multicrew %v", undocked.Taxi,
+			undocked.Multicrew)
+	}
+
+	if undocked.FleetCarrier() {
+		t.Errorf("Outpost reported as a fleet carrier")
+	}
+}
+
+func TestParseJournalUndockedFleetCarrier(t *testing.T) {
+	msg := parseJournalFixture(t, `{
+		"timestamp": "2021-05-25T18:10:42Z",
+		"event": "Undocked",
+		"StationName": "K7Q-BQL",
+		"StationType": "FleetCarrier",
+		"MarketID": 3700005632,
+		"StarSystem": "Pleione",
+		"StarPos": [-77.0, -146.78125, -344.125]
+	}`)
+
+	if undocked := msg.(JournalUndocked); !undocked.FleetCarrier() {
+		t.Errorf("Expected a fleet carrier, got %+v", undocked)
+	}
+}
+
+func TestJournalCommonFields(t *testing.T) {
+	events := []string{"FSDJump", "Docked", "FSSDiscoveryScan", "SAASignalsFound",
+		"CarrierJump", "Location", "NavBeaconScan", "Undocked"}
+
+	for _, event := range events {
+		msg := parseJournalFixture(t, `{
+			"timestamp": "2021-05-25T18:06:08Z",
+			"event": "`+event+`",
+			"StarSystem": "Pleione",
+			"SystemAddress": 2862335682961,
+			"StarPos": [-77.0, -146.78125, -344.125]
+		}`)
+
+		system, ok := msg.(interface{ System() string })
+
+		if !ok {
+			t.Errorf("%s: expected System method on %T", event, msg)
+			continue
+		}
+
+		if system.System() != "Pleione" {
+			t.Errorf("%s: unexpected system %q", event, system.System())
+		}
+	}
+
+	star := parseJournalFixture(t, `{
+		"timestamp": "2021-05-25T18:06:08Z",
+		"event": "Scan",
+		"StarType": "B",
+		"StarSystem": "Pleione",
+		"SystemAddress": 2862335682961,
+		"StarPos": [-77.0, -146.78125, -344.125]
+	}`).(JournalScanStar)
+
+	if star.Event != "Scan" || star.Timestamp != "2021-05-25T18:06:08Z" ||
+		star.SystemAddress != 2862335682961 || star.System() != "Pleione" {
+		t.Errorf("Unexpected star: %+v", star.CommonJournal)
+	}
+}
+
+func TestJournalEvent(t *testing.T) {
+	result, err := parseJSON(journalFixture(`{
+		"timestamp": "2021-05-25T17:58:12Z",
+		"event": "NavBeaconScan",
+		"SystemAddress": 2862335682961,
+		"NumBodies": 23,
+		"StarSystem": "Pleione",
+		"StarPos": [-77.0, -146.78125, -344.125]
+	}`))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	event, ok := result.Message.(Journal).Event()
+
+	if !ok {
+		t.Fatalf("Expected a JournalEvent, got %T", result.Message.(Journal).Message)
+	}
+
+	when, err := event.EventTime()
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if event.EventName() != "NavBeaconScan" || event.System() != "Pleione" ||
+		!when.Equal(time.Date(2021, 5, 25, 17, 58, 12, 0, time.UTC)) {
+		t.Errorf("Unexpected event: %s in %s at %v", event.EventName(),
+			event.System(), when)
+	}
+
+	if _, ok := (Journal{Message: "custom"}).Event(); ok {
+		t.Errorf("Expected a non JournalEvent message to not be ok")
+	}
+}
+
+func TestHandleJournalMessageInvalidEvent(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  map[string]interface{}
+	}{
+		{"missing", map[string]interface{}{"StarSystem": "Eranin"}},
+		{"number", map[string]interface{}{"event": 42.0, "StarSystem": "Eranin"}},
+		{"null", map[string]interface{}{"event": nil, "StarSystem": "Eranin"}},
+	}
+
+	for _, test := range tests {
+		_, err := handleJournalMessage(test.msg)
+
+		var invalid *InvalidEventError
+
+		if !errors.As(err, &invalid) {
+			t.Errorf("%s: expected InvalidEventError, got %v", test.name, err)
+			continue
+		}
+
+		if invalid.Event != test.msg["event"] {
+			t.Errorf("%s: unexpected event %v", test.name, invalid.Event)
+		}
+	}
+
+	_, err := handleJournalMessage(map[string]interface{}{"event": 42.0})
+
+	if err.Error() != "journal event 42 is a float64, not a string" {
+		t.Errorf("Unexpected error message: %q", err)
+	}
+}
+
+func TestHandleJournalMessageDecodeError(t *testing.T) {
+	_, err := handleJournalMessage(map[string]interface{}{
+		"event":       "Docked",
+		"StarSystem":  "Pleione",
+		"StationName": map[string]interface{}{"unexpected": true}})
+
+	var decodeErr *JournalDecodeError
+
+	if !errors.As(err, &decodeErr) || decodeErr.Event != "Docked" {
+		t.Fatalf("Expected JournalDecodeError for Docked, got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "StationName") {
+		t.Errorf("Expected the broken field in the error, got %q", err)
+	}
+
+	// The error is still available through the DecodeError of the message.
+	msg := `{
+		"$schemaRef": "https://eddn.edcd.io/schemas/journal/1",
+		"header": {},
+		"message": {"event": "Docked", "StarSystem": "Pleione", "StationName": 42}
+	}`
+
+	if _, err := parseJSON(msg); !errors.As(err, &decodeErr) {
+		t.Errorf("Expected JournalDecodeError from parseJSON, got %v", err)
+	}
+}
+
+func TestJournalDecodeOptions(t *testing.T) {
+	event := map[string]interface{}{
+		"event":          "Docked",
+		"StarSystem":     "Pleione",
+		"StationName":    "Pleione Orbital",
+		"DistFromStarLS": "843.5",
+		"NewField":       true}
+
+	if _, err := handleJournalMessage(event); err == nil {
+		t.Fatalf("Expected error decoding a string DistFromStarLS strictly")
+	}
+
+	var unused []string
+
+	out, err := ParseJournalEventWithOptions(event, ParseOptions{
+		JournalDecode: JournalDecodeOptions{
+			WeaklyTypedInput: true,
+			OnUnused: func(event string, fields []string) {
+				if event == "Docked" {
+					unused = fields
+				}
+			}}})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if docked := out.(JournalDocked); docked.DistFromStarLS != 843.5 {
+		t.Errorf("Unexpected DistFromStarLS: %v", docked.DistFromStarLS)
+	}
+
+	if len(unused) != 1 || unused[0] != "NewField" {
+		t.Errorf("Unexpected unused fields: %v", unused)
+	}
+
+	// The options only apply to the parser they're given to.
+	if _, err := handleJournalMessage(event); err == nil {
+		t.Errorf("Expected the default options to still decode strictly")
+	}
+}
+
+func TestChannelInterfaceJournalDecodeOptions(t *testing.T) {
+	frame := compress(t, journalFixture(`{"timestamp": "2021-05-25T18:06:08Z",
+		"event": "Docked", "StarSystem": "Pleione",
+		"StationName": "Pleione Orbital", "DistFromStarLS": "843.5"}`))
+
+	weak := newChannelInterface(FilterNone, ChannelInterfaceConfig{
+		BufferSize:    1,
+		JournalDecode: JournalDecodeOptions{WeaklyTypedInput: true}}.withDefaults())
+	strict := newChannelInterface(FilterNone,
+		ChannelInterfaceConfig{BufferSize: 1}.withDefaults())
+
+	weak.handleMessage(context.Background(), frame)
+	strict.handleMessage(context.Background(), frame)
+
+	if stats := weak.Stats(); stats.Parsed != 1 {
+		t.Errorf("Unexpected stats with WeaklyTypedInput: %+v", stats)
+	}
+
+	if stats := strict.Stats(); stats.ParseErrors != 1 {
+		t.Errorf("Unexpected stats without WeaklyTypedInput: %+v", stats)
+	}
+}
+
+func TestScanFilter(t *testing.T) {
+	planet := map[string]interface{}{
+		"event":          "Scan",
+		"BodyName":       "Pleione 3",
+		"PlanetClass":    "Icy body",
+		"TerraformState": ""}
+	docked := map[string]interface{}{"event": "Docked", "StarSystem": "Pleione"}
+
+	var seen []string
+
+	p := &parser{options: ParseOptions{
+		ScanFilter: func(scan map[string]interface{}) bool {
+			seen = append(seen, scan["event"].(string))
+			return scan["TerraformState"] == "Terraformable"
+		}}}
+
+	_, err := p.handleJournalMessage(planet)
+
+	var filtered *ScanFilteredError
+
+	if !errors.As(err, &filtered) || filtered.BodyName != "Pleione 3" {
+		t.Errorf("Expected ScanFilteredError for Pleione 3, got %v", err)
+	}
+
+	if _, err = p.handleJournalMessage(docked); err != nil {
+		t.Errorf("Unexpected error for a Docked event: %v", err)
+	}
+
+	if len(seen) != 1 || seen[0] != "Scan" {
+		t.Errorf("Expected the filter to see only the Scan event, saw %v", seen)
+	}
+
+	// The filter only applies to the parser it's given to.
+	if _, err = handleJournalMessage(planet); err != nil {
+		t.Errorf("Unexpected error without the filter: %v", err)
+	}
+
+	planet["TerraformState"] = "Terraformable"
+
+	if out, err := p.handleJournalMessage(planet); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	} else if _, ok := out.(JournalScanPlanet); !ok {
+		t.Errorf("Expected JournalScanPlanet, got %T", out)
+	}
+}
+
+func TestChannelInterfaceScanFilter(t *testing.T) {
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{
+		BufferSize: 1,
+		ScanFilter: func(scan map[string]interface{}) bool {
+			return scan["TerraformState"] == "Terraformable"
+		}}.withDefaults())
+
+	ci.handleMessage(context.Background(), compress(t, journalFixture(
+		`{"timestamp": "2021-05-25T18:06:08Z", "event": "Scan",
+		"BodyName": "Pleione 3", "PlanetClass": "Icy body"}`)))
+
+	if stats := ci.Stats(); stats.Filtered != 1 || stats.Parsed != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestClassifyScan(t *testing.T) {
+	tests := []struct {
+		msg  map[string]interface{}
+		kind scanKind
+	}{
+		{map[string]interface{}{"StarType": "K", "BodyName": "Pleione"}, scanStar},
+		{map[string]interface{}{"StarType": "K", "ScanType": "AutoScan"}, scanStar},
+		{map[string]interface{}{"StarType": "Y", "PlanetClass": "Gas giant"}, scanStar},
+		{map[string]interface{}{"PlanetClass": "Icy body", "BodyName": "Pleione 5"}, scanPlanet},
+		{map[string]interface{}{"PlanetClass": "Icy body", "BodyName": "Pleione 5 A Ring"}, scanPlanet},
+		{map[string]interface{}{"BodyName": "Pleione A Belt Cluster 4"}, scanBeltCluster},
+		{map[string]interface{}{"BodyName": "Pleione 5 A Ring"}, scanRing},
+		{map[string]interface{}{"BodyName": "Pleione 5 A Ring", "ScanType": "NavBeaconDetail"}, scanRing},
+		{map[string]interface{}{"BodyName": "Pleione 5 Ringworld"}, scanUnknown},
+		{map[string]interface{}{"BodyName": 42}, scanUnknown},
+		{map[string]interface{}{}, scanUnknown},
+	}
+
+	for _, test := range tests {
+		if kind := classifyScan(test.msg); kind != test.kind {
+			t.Errorf("classifyScan(%v) = %d, want %d", test.msg, kind, test.kind)
+		}
+	}
+}
+
+func TestParseJournalEvent(t *testing.T) {
+	var event map[string]interface{}
+
+	// A line of the game's own journal, which has no StarPos.
+	line := `{"timestamp": "2021-05-25T18:06:08Z", "event": "FSSDiscoveryScan",
+		"Progress": 0.436366, "BodyCount": 23, "NonBodyCount": 40,
+		"SystemName": "Pleione", "SystemAddress": 2862335682961}`
+
+	if err := json.Unmarshal([]byte(line), &event); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ParseJournalEvent(event)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if scan, ok := out.(JournalFSSDiscoveryScan); !ok {
+		t.Errorf("Expected JournalFSSDiscoveryScan, got %T", out)
+	} else if scan.BodyCount != 23 || scan.SystemName != "Pleione" {
+		t.Errorf("Unexpected scan: %+v", scan)
+	}
+
+	var unhandled *UnhandledEventError
+
+	event["event"] = "Music"
+
+	if _, err = ParseJournalEvent(event); !errors.As(err, &unhandled) {
+		t.Errorf("Expected UnhandledEventError, got %v", err)
+	}
+}
+
+func TestParseJournalLargeSystemAddress(t *testing.T) {
+	// 2^55 + 3 can't be held exactly by a float64.
+	const address = 36028797018963971
+
+	if int64(float64(address)) == address {
+		t.Fatalf("%d survives a float64, so doesn't test anything", address)
+	}
+
+	msg := parseJournalFixture(t, `{
+		"timestamp": "2021-05-25T18:06:08Z",
+		"event": "FSDJump",
+		"StarSystem": "Pleione",
+		"SystemAddress": 36028797018963971,
+		"StarPos": [-77.0, -146.78125, -344.125]
+	}`)
+
+	jump := msg.(JournalFSDJump)
+
+	if jump.SystemAddress != address {
+		t.Errorf("Expected SystemAddress %d, got %d", int64(address), jump.SystemAddress)
+	}
+
+	// Smaller numbers still reach the scan filter as a float64.
+	event := map[string]interface{}{"event": "Scan", "BodyName": "Pleione",
+		"StarType": "B", "SystemAddress": json.Number("36028797018963971"),
+		"DistanceFromArrivalLS": json.Number("0.5")}
+
+	filter := func(scan map[string]interface{}) bool {
+		if scan["SystemAddress"] != int64(address) || scan["DistanceFromArrivalLS"] != 0.5 {
+			t.Errorf("Unexpected numbers: %#v", scan)
+		}
+
+		return true
+	}
+
+	if _, err := ParseJournalEventWithOptions(event,
+		ParseOptions{ScanFilter: filter}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
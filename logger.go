@@ -0,0 +1,44 @@
+package EDDNClient
+
+import (
+	"sync"
+)
+
+// Logger is the interface used for any diagnostic output produced by the
+// package.  *log.Logger satisfies this interface.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// nopLogger is the default Logger and discards everything.
+type nopLogger struct{}
+
+func (nopLogger) Printf(format string, v ...interface{}) {}
+
+var (
+	loggerMutex sync.RWMutex
+	logger      Logger = nopLogger{}
+)
+
+// SetLogger sets the Logger used for diagnostic output by the parser, the
+// ChannelInterface, and the Uploader.  By default nothing is logged.  Passing
+// nil restores the default.  Errors are still returned as usual regardless of
+// the logger used.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+
+	loggerMutex.Lock()
+	logger = l
+	loggerMutex.Unlock()
+}
+
+// logf writes to the current Logger.
+func logf(format string, v ...interface{}) {
+	loggerMutex.RLock()
+	l := logger
+	loggerMutex.RUnlock()
+
+	l.Printf(format, v...)
+}
@@ -0,0 +1,39 @@
+package EDDNClient
+
+import (
+	"fmt"
+	"testing"
+)
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (r *recordingLogger) Printf(format string, v ...interface{}) {
+	r.lines = append(r.lines, fmt.Sprintf(format, v...))
+}
+
+func TestSetLogger(t *testing.T) {
+	recorder := &recordingLogger{}
+
+	SetLogger(recorder)
+	defer SetLogger(nil)
+
+	if _, err := parseJSON("not json"); err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	if len(recorder.lines) != 1 {
+		t.Fatalf("Expected 1 logged line, got %v", recorder.lines)
+	}
+
+	SetLogger(nil)
+
+	if _, err := parseJSON("not json"); err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	if len(recorder.lines) != 1 {
+		t.Errorf("Expected nothing logged after reset, got %v", recorder.lines)
+	}
+}
@@ -0,0 +1,72 @@
+package EDDNClient
+
+// MessageType returns a short name for the type of m, which may be any
+// message returned by the parser, or a journal event.  i.e. "commodity", or
+// "journal.FSDJump".  The names are stable, so they're suitable for metrics
+// labels, and the older versions of a schema share the name of the current
+// one.  Journals name their event, and every kind of Scan is "journal.Scan".
+// Journal events decoded by a JournalEventDecoder registered by the receiver
+// are just "journal", and anything else is "".
+func MessageType(m interface{}) string {
+	switch msg := m.(type) {
+	case Commodity:
+		return "commodity"
+	case Shipyard, Shipyard1:
+		return "shipyard"
+	case Outfitting, Outfitting1:
+		return "outfitting"
+	case Blackmarket:
+		return "blackmarket"
+	case ApproachSettlement:
+		return "approachsettlement"
+	case NavRoute:
+		return "navroute"
+	case FSSSignalDiscovered:
+		return "fsssignaldiscovered"
+	case CodexEntry:
+		return "codexentry"
+	case FSSBodySignals:
+		return "fssbodysignals"
+	case ScanBaryCentre:
+		return "scanbarycentre"
+	case FCMaterials:
+		return "fcmaterials"
+	case RawUnknown:
+		return "unknown"
+	case Journal:
+		if event := journalEventType(msg.Message); event != "" {
+			return event
+		}
+
+		return "journal"
+	}
+
+	return journalEventType(m)
+}
+
+// journalEventType returns the MessageType of the journal event m, or "" if
+// it isn't one of the built-in event types.
+func journalEventType(m interface{}) string {
+	switch m.(type) {
+	case JournalDocked:
+		return "journal.Docked"
+	case JournalUndocked:
+		return "journal.Undocked"
+	case JournalFSDJump:
+		return "journal.FSDJump"
+	case JournalScanStar, JournalScanPlanet, JournalScanBeltCluster, JournalScanRing:
+		return "journal.Scan"
+	case JournalFSSDiscoveryScan:
+		return "journal.FSSDiscoveryScan"
+	case JournalSAASignalsFound:
+		return "journal.SAASignalsFound"
+	case JournalCarrierJump:
+		return "journal.CarrierJump"
+	case JournalLocation:
+		return "journal.Location"
+	case JournalNavBeaconScan:
+		return "journal.NavBeaconScan"
+	}
+
+	return ""
+}
@@ -0,0 +1,52 @@
+package EDDNClient
+
+import (
+	"testing"
+)
+
+func TestMessageType(t *testing.T) {
+	tests := []struct {
+		msg  interface{}
+		name string
+	}{
+		{Commodity{}, "commodity"},
+		{Shipyard{}, "shipyard"},
+		{Shipyard1{}, "shipyard"},
+		{Outfitting{}, "outfitting"},
+		{Outfitting1{}, "outfitting"},
+		{Blackmarket{}, "blackmarket"},
+		{ApproachSettlement{}, "approachsettlement"},
+		{NavRoute{}, "navroute"},
+		{FSSSignalDiscovered{}, "fsssignaldiscovered"},
+		{CodexEntry{}, "codexentry"},
+		{FSSBodySignals{}, "fssbodysignals"},
+		{ScanBaryCentre{}, "scanbarycentre"},
+		{FCMaterials{}, "fcmaterials"},
+		{RawUnknown{}, "unknown"},
+		{Journal{Message: JournalFSDJump{}}, "journal.FSDJump"},
+		{Journal{Message: JournalScanRing{}}, "journal.Scan"},
+		{Journal{Message: map[string]interface{}{}}, "journal"},
+		{JournalUndocked{}, "journal.Undocked"},
+		{&Commodity{}, ""},
+		{nil, ""},
+	}
+
+	for _, test := range tests {
+		if name := MessageType(test.msg); name != test.name {
+			t.Errorf("MessageType(%T) = %q, want %q", test.msg, name, test.name)
+		}
+	}
+
+	// Every message the parser returns is named.
+	for _, fixture := range []string{commodity2Fixture, navRouteFixture} {
+		result, err := ParseMessage([]byte(fixture))
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if MessageType(result.Message) == "" {
+			t.Errorf("%T has no MessageType", result.Message)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package EDDNClient
+
+import (
+	"errors"
+	"time"
+)
+
+// Reasons given to MetricsCollector.ObserveDrop for a message that was
+// received, but not delivered.
+const (
+	DropFiltered  = "filtered"  // Disregarded by the header, or scan filters
+	DropDuplicate = "duplicate" // Disregarded by EnableDedup
+	DropOverflow  = "overflow"  // Dropped due to the OverflowPolicy
+	DropSampled   = "sampled"   // Disregarded by SampleEvery, or RateLimits
+)
+
+// MetricsCollector receives metrics from a ChannelInterface as each message
+// is handled, allowing them to be exported to a monitoring system such as
+// Prometheus.  Its methods are called from the ChannelInterface goroutines,
+// concurrently when there is more than one worker, and must not block.
+type MetricsCollector interface {
+	// ObserveParse is called after each message is parsed with its
+	// $schemaRef, how long it took to decompress and decode, and the error
+	// if it failed.  schemaRef is empty if the message was too malformed to
+	// find it.
+	ObserveParse(schemaRef string, duration time.Duration, err error)
+
+	// ObserveDrop is called for each message that isn't delivered with one
+	// of the Drop reasons.
+	ObserveDrop(reason string)
+}
+
+// DecodeCollector may be implemented by a MetricsCollector to also receive
+// how long each message took to decode into its type, excluding the time
+// spent decompressing it.  This separates the cost of the decoders, such as
+// mapstructure for journal events, from that of the message's size.
+type DecodeCollector interface {
+	// ObserveDecode is called after each message is parsed successfully
+	// with its $schemaRef, and how long it took to decode.
+	ObserveDecode(schemaRef string, duration time.Duration)
+}
+
+// noopMetrics is the MetricsCollector used when none is configured.
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveParse(string, time.Duration, error) {}
+func (noopMetrics) ObserveDrop(string)                        {}
+
+// metrics returns the MetricsCollector of ci.
+func (ci *ChannelInterface) metrics() MetricsCollector {
+	if ci.config.Metrics == nil {
+		return noopMetrics{}
+	}
+
+	return ci.config.Metrics
+}
+
+// errorSchemaRef returns the $schemaRef of the message that caused err, if
+// it's known.
+func errorSchemaRef(err error) string {
+	var unsupported *UnsupportedSchemaError
+	var invalid *ValidationError
+	var decodeErr *DecodeError
+	var batch *BatchMessageError
+
+	switch {
+	case errors.As(err, &unsupported):
+		return unsupported.Ref
+	case errors.As(err, &invalid):
+		return invalid.SchemaRef
+	case errors.As(err, &decodeErr):
+		return decodeErr.SchemaRef
+	case errors.As(err, &batch):
+		return batch.SchemaRef
+	}
+
+	return ""
+}
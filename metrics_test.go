@@ -0,0 +1,96 @@
+package EDDNClient
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testMetrics records everything observed by a ChannelInterface.
+type testMetrics struct {
+	mutex   sync.Mutex
+	parses  map[string]int
+	errors  map[string]int
+	drops   map[string]int
+	decodes map[string]int
+	slowest time.Duration
+}
+
+func newTestMetrics() *testMetrics {
+	return &testMetrics{
+		parses:  make(map[string]int),
+		errors:  make(map[string]int),
+		drops:   make(map[string]int),
+		decodes: make(map[string]int)}
+}
+
+func (m *testMetrics) ObserveParse(schemaRef string, duration time.Duration, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.parses[schemaRef]++
+
+	if duration > m.slowest {
+		m.slowest = duration
+	}
+
+	if err != nil {
+		m.errors[schemaRef]++
+	}
+}
+
+func (m *testMetrics) ObserveDecode(schemaRef string, duration time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.decodes[schemaRef]++
+}
+
+func (m *testMetrics) ObserveDrop(reason string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.drops[reason]++
+}
+
+func TestChannelInterfaceMetrics(t *testing.T) {
+	metrics := newTestMetrics()
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{
+		Metrics:        metrics,
+		OverflowPolicy: OverflowDropNewest}.withDefaults())
+	ref := "http://schemas.elite-markets.net/eddn/commodity/2"
+	unsupported := strings.Replace(commodity2Fixture, "commodity/2", "commodity/4", 1)
+
+	ci.SetSoftwareFilter(nil, []string{"Broken Uploader"})
+
+	// Nothing is reading so the message is dropped once parsed.
+	ci.handleMessage(context.Background(), compress(t, commodity2Fixture))
+	ci.handleMessage(context.Background(), compress(t, unsupported))
+	ci.handleMessage(context.Background(), "\x78\x9cthis is not deflate data")
+	ci.handleMessage(context.Background(), compress(t, strings.Replace(
+		commodity2Fixture, "My Awesome Market Uploader", "Broken Uploader", 1)))
+
+	if metrics.parses[ref] != 1 || metrics.errors[ref] != 0 {
+		t.Errorf("Unexpected parses of %s: %v", ref, metrics.parses)
+	}
+
+	if metrics.errors[strings.Replace(ref, "commodity/2", "commodity/4", 1)] != 1 ||
+		metrics.errors[""] != 1 {
+		t.Errorf("Unexpected parse errors: %v", metrics.errors)
+	}
+
+	if metrics.drops[DropOverflow] != 1 || metrics.drops[DropFiltered] != 1 {
+		t.Errorf("Unexpected drops: %v", metrics.drops)
+	}
+
+	// Only messages that parsed successfully are decoded.
+	if len(metrics.decodes) != 1 || metrics.decodes[ref] != 1 {
+		t.Errorf("Unexpected decodes: %v", metrics.decodes)
+	}
+
+	if metrics.slowest <= 0 {
+		t.Errorf("Expected parse durations to be recorded")
+	}
+}
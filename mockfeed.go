@@ -0,0 +1,59 @@
+package EDDNClient
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+)
+
+// NewMockChannelInterface creates a ChannelInterface that replays frames
+// instead of connecting to a relay, which allows the receiver to test their
+// handling of messages without a network connection.  Each frame is parsed,
+// filtered, and delivered exactly as if it had been received from EDDN, in
+// the order given.  frames may be zlib compressed as they are on the wire, or
+// plain JSON.  See CompressFrame.
+//
+// Once every frame has been handled the ChannelInterface is closed, so the
+// receiver may simply read until Done is closed.  The options in config
+// concerning the relay connection, such as Address, are ignored.
+func NewMockChannelInterface(ctx context.Context, filter int,
+	config ChannelInterfaceConfig, frames [][]byte) *ChannelInterface {
+
+	config = config.withDefaults()
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	ci := newChannelInterface(filter, config)
+	ci.cancel = cancel
+
+	go ci.run(ctx, replay(frames))
+
+	return ci
+}
+
+// replay returns a frameSource providing each of frames in turn.
+func replay(frames [][]byte) frameSource {
+	return func(ctx context.Context) (frame string, ok bool, stop bool) {
+		if len(frames) == 0 {
+			return "", false, true
+		}
+
+		frame, frames = string(frames[0]), frames[1:]
+
+		return frame, true, false
+	}
+}
+
+// CompressFrame zlib compresses a JSON message the same way EDDN does on the
+// wire, for use as a frame given to NewMockChannelInterface.
+func CompressFrame(json string) []byte {
+	var buf bytes.Buffer
+
+	w := zlib.NewWriter(&buf)
+
+	// Writes to a bytes.Buffer can't fail.
+	w.Write([]byte(json))
+	w.Close()
+
+	return buf.Bytes()
+}
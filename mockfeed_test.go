@@ -0,0 +1,132 @@
+package EDDNClient
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMockChannelInterface(t *testing.T) {
+	frames := [][]byte{
+		CompressFrame(commodity2Fixture),
+		[]byte("not a message"),
+		[]byte(navRouteFixture),
+		CompressFrame(commodity1Fixture),
+	}
+
+	ci := NewMockChannelInterface(context.Background(), FilterNone,
+		ChannelInterfaceConfig{}, frames)
+
+	var commodities []Commodity
+	var routes []NavRoute
+
+	timeout := time.After(5 * time.Second)
+
+	for done := false; !done; {
+		select {
+		case msg, ok := <-ci.CommodityChan:
+			if ok {
+				commodities = append(commodities, msg)
+			}
+		case msg, ok := <-ci.NavRouteChan:
+			if ok {
+				routes = append(routes, msg)
+			}
+		case <-ci.Done:
+			done = true
+		case <-timeout:
+			t.Fatalf("MockChannelInterface was not closed")
+		}
+	}
+
+	if len(commodities) != 2 || len(routes) != 1 {
+		t.Fatalf("Expected 2 commodities, and 1 route, got %d, and %d",
+			len(commodities), len(routes))
+	}
+
+	// Messages are delivered in order with a single worker.
+	if commodities[0].SchemaRef == commodities[1].SchemaRef {
+		t.Errorf("Unexpected commodities: %+v", commodities)
+	}
+
+	stats := ci.Stats()
+
+	if stats.Received != 4 || stats.Parsed != 3 || stats.ParseErrors != 1 ||
+		stats.State != StateStopped {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestMockChannelInterfaceFilter(t *testing.T) {
+	frames := [][]byte{
+		CompressFrame(commodity2Fixture),
+		CompressFrame(navRouteFixture),
+	}
+
+	ci := NewMockChannelInterface(context.Background(), FilterCommodity,
+		ChannelInterfaceConfig{}, frames)
+
+	select {
+	case msg := <-ci.NavRouteChan:
+		if len(msg.Message.Route) != 3 {
+			t.Errorf("Unexpected route: %+v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected a route")
+	}
+
+	select {
+	case <-ci.Done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("MockChannelInterface was not closed")
+	}
+
+	if _, ok := <-ci.CommodityChan; ok {
+		t.Errorf("Expected commodity to be filtered")
+	}
+}
+
+func TestMockChannelInterfaceRawFrames(t *testing.T) {
+	frames := [][]byte{
+		CompressFrame(commodity2Fixture),
+		CompressFrame(navRouteFixture),
+	}
+
+	// Frames are sent even for filtered messages.
+	ci := NewMockChannelInterface(context.Background(), FilterNavRoute,
+		ChannelInterfaceConfig{RawFrames: true}, frames)
+
+	var raw []string
+	var commodities int
+
+	timeout := time.After(5 * time.Second)
+
+	for done := false; !done; {
+		select {
+		case frame, ok := <-ci.RawFrames():
+			if ok {
+				raw = append(raw, string(frame))
+			}
+		case _, ok := <-ci.CommodityChan:
+			if ok {
+				commodities++
+			}
+		case <-ci.Done:
+			done = true
+		case <-timeout:
+			t.Fatalf("MockChannelInterface was not closed")
+		}
+	}
+
+	if len(raw) != 2 || raw[0] != commodity2Fixture || raw[1] != navRouteFixture {
+		t.Errorf("Unexpected raw frames: %q", raw)
+	}
+
+	if commodities != 1 {
+		t.Errorf("Expected 1 commodity, got %d", commodities)
+	}
+
+	if (&ChannelInterface{}).RawFrames() != nil {
+		t.Errorf("Expected no RawFrames channel without RawFrames set")
+	}
+}
@@ -0,0 +1,24 @@
+package EDDNClient
+
+// NavRouteSystem describes a single system along a plotted route.
+type NavRouteSystem struct {
+	StarClass     string  `json:"StarClass"`     // Required
+	StarPos       StarPos `json:"StarPos"`       // Required
+	StarSystem    string  `json:"StarSystem"`    // Required
+	SystemAddress int64   `json:"SystemAddress"` // Required
+}
+
+// NavRouteMessage contains the route plotted by a commander sent to EDDN.
+// The first system in Route is the system the route was plotted from.
+type NavRouteMessage struct {
+	Event     string           `json:"event"`     // Required
+	Route     []NavRouteSystem `json:"Route"`     // Required
+	Timestamp string           `json:"timestamp"` // Required
+}
+
+// NavRoute is the high level type that contains the entire JSON message.
+type NavRoute struct {
+	SchemaRef string          `json:"$schemaRef"`
+	Header    Header          `json:"header"`
+	Message   NavRouteMessage `json:"message"`
+}
@@ -14,3 +14,54 @@ type Outfitting struct {
 	Header    Header            `json:"header"`
 	Message   OutfittingMessage `json:"message"`
 }
+
+// Outfitting1Module describes a single module sent in a version 1 message.
+// The category, mount, guidance, ship, class, rating, and entitlement fields
+// were all dropped in version 2 in favour of a single symbolic name.
+type Outfitting1Module struct {
+	Category    string `json:"category"` // Required
+	Class       string `json:"class"`    // Required
+	Entitlement string `json:"entitlement,omitempty"`
+	Guidance    string `json:"guidance,omitempty"`
+	Mount       string `json:"mount,omitempty"`
+	Name        string `json:"name"`   // Required
+	Rating      string `json:"rating"` // Required
+	Ship        string `json:"ship,omitempty"`
+}
+
+// Outfitting1Message contains the outfitting data sent to EDDN using the
+// version 1 schema.
+type Outfitting1Message struct {
+	Modules     []Outfitting1Module `json:"modules"`     // Required
+	StationName string              `json:"stationName"` // Required
+	SystemName  string              `json:"systemName"`  // Required
+	Timestamp   string              `json:"timestamp"`   // Required
+}
+
+// Outfitting1 is the high level type that contains the entire version 1 JSON
+// message, as returned by ParseMessage.  The ChannelInterface sends it on
+// OutfittingChan converted to an Outfitting, and only a handler registered
+// with On for Outfitting1 is given the fields version 2 dropped.
+type Outfitting1 struct {
+	SchemaRef string             `json:"$schemaRef"`
+	Header    Header             `json:"header"`
+	Message   Outfitting1Message `json:"message"`
+}
+
+// Outfitting converts a version 1 message into the current Outfitting type.
+// Only the module names are kept so the modules can be iterated the same way
+// as version 2 messages.  The schema reference is left untouched so the
+// receiver can still tell which version the data originally came from.
+func (o Outfitting1) Outfitting() Outfitting {
+	modules := make([]string, 0, len(o.Message.Modules))
+
+	for _, module := range o.Message.Modules {
+		modules = append(modules, module.Name)
+	}
+
+	return Outfitting{o.SchemaRef, o.Header, OutfittingMessage{
+		Modules:     modules,
+		StationName: o.Message.StationName,
+		SystemName:  o.Message.SystemName,
+		Timestamp:   o.Message.Timestamp}}
+}
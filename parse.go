@@ -1,17 +1,26 @@
 package EDDNClient
 
 import (
+	"bytes"
+	"compress/gzip"
 	"compress/zlib"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/mitchellh/mapstructure"
-	"io/ioutil"
+	"io"
 	"strings"
+	"sync"
+	"time"
 )
 
+// DefaultMaxMessageSize is the default maximum size of a message once it's
+// decompressed.  Real EDDN messages are rarely more than a few hundred KB.
+const DefaultMaxMessageSize = 4 << 20
+
 var (
-	errUnhandledSchema = errors.New("schema not supported")
+	errFiltered = errors.New("message filtered")
 )
 
 // Root is the root of every JSON message received from EDDN.  This should
@@ -20,164 +29,1034 @@ type Root struct {
 	SchemaRef string          `json:"$schemaRef"` // The schema of the message
 	Header    Header          `json:"header"`     // The message header
 	Message   json.RawMessage `json:"message"`    // The message unparsed until later
+
+	options *ParseOptions // Options the message is decoded with
 }
 
 // Header type that is common to all messages.  This bit is only used by the parser
 // however.  The types sent by the ChannelInterface will have their own
 // Root/Header types that the receiver should use.
+//
+// Horizons, and Odyssey are only sent by newer uploaders, and are nil when
+// they weren't sent so that legacy data can be told apart from data where
+// the uploader wasn't running the expansion.
 type Header struct {
 	GatewayTimestamp string `json:"gatewayTimestamp,omitempty"` // Timestamp
 	SoftwareName     string `json:"softwareName"`               // Software that sent the data
 	SoftwareVersion  string `json:"softwareVersion"`            // Software version
 	UploaderID       string `json:"uploaderID"`                 // ID of the uploader
+	Horizons         *bool  `json:"horizons,omitempty"`         // Whether the game was running Horizons
+	Odyssey          *bool  `json:"odyssey,omitempty"`          // Whether the game was running Odyssey
+}
+
+// ParseResult is returned by ParseMessage and contains the parsed message
+// along with the schema reference, and header it was sent with.
+type ParseResult struct {
+	SchemaRef string      // The schema of the message
+	Header    Header      // The message header
+	Message   interface{} // The parsed message.  i.e. Commodity, or Journal
+	IsTest    bool        // Whether the message uses a "/test" schema
+	Raw       []byte      // The decompressed message.  (Only with IncludeRaw.)
+
+	// How long the message took to parse in total, and how much of that was
+	// spent decoding the message into its type, as opposed to decompressing
+	// it and finding its schema.  (Only with Timing.)
+	Duration       time.Duration
+	DecodeDuration time.Duration
+}
+
+// RawUnknown is returned as the Message of a ParseResult in place of an
+// *UnsupportedSchemaError when DeliverUnknown is set.  Message contains the
+// message exactly as it was received so the receiver may decode it itself.
+type RawUnknown struct {
+	SchemaRef string          // The schema of the message
+	Header    Header          // The message header
+	Message   json.RawMessage // The undecoded message
+}
+
+// ParseOptions contains the options used by ParseMessageWithOptions.  The
+// zero value is equivalent to ParseMessage.
+type ParseOptions struct {
+	// Return messages using a schema that isn't supported as a RawUnknown
+	// rather than an *UnsupportedSchemaError.  Test schemas are still
+	// disregarded unless IncludeTest is set.
+	DeliverUnknown bool
+
+	// Decode messages using the "/test" variant of a schema rather than
+	// returning an *UnsupportedSchemaError.  Test messages are decoded the
+	// same way as any other, and have IsTest set in their ParseResult.
+	IncludeTest bool
+
+	// Maximum size of a message once it's decompressed.
+	// DefaultMaxMessageSize by default.
+	MaxMessageSize int64
+
+	// Attach the decompressed message to the ParseResult as Raw.  Useful
+	// for auditing, or for finding out why a message decoded the way it
+	// did.
+	IncludeRaw bool
+
+	// Check that each message contains the fields its schema requires
+	// before decoding it, returning a *ValidationError if any are missing
+	// or empty.  Only the built-in schemas are checked.
+	Validate bool
+
+	// Record how long each message took to parse as the Duration, and
+	// DecodeDuration of its ParseResult.  The clock isn't read otherwise.
+	Timing bool
+
+	// Return an error for messages containing fields their Go type doesn't
+	// have, or anything after the message, rather than ignoring them.  This
+	// is useful for a canary receiver that detects when EDDN adds fields.
+	// Only the envelope, and the built-in schemas are checked.  Journal
+	// events are decoded with mapstructure, so their unknown fields are
+	// found with JournalDecode.OnUnused instead.
+	Strict bool
+
+	// Options used to decode journal events with mapstructure.  The zero
+	// value decodes each field strictly.
+	JournalDecode JournalDecodeOptions
+
+	// ScanFilter, when set, is called with each Scan event before it's
+	// decoded, as the event's fields keyed by their journal names.  Events
+	// it returns false for aren't decoded, and return a *ScanFilteredError
+	// instead, which saves decoding bodies the receiver will discard.  e.g.
+	// keeping only terraformable planets:
+	//
+	//	func(scan map[string]interface{}) bool {
+	//		return scan["TerraformState"] == "Terraformable"
+	//	}
+	//
+	// Numbers in scan are float64, except for integers too large for a
+	// float64 to hold exactly, such as the SystemAddress, which are int64.
+	// The filter may be called from any goroutine parsing messages.
+	ScanFilter func(scan map[string]interface{}) bool
+}
+
+func init() {
+	registerSchema("commodity/1", decodeCommodity1)
+	registerSchema("commodity/2", decodeCommodity2)
+	registerSchema("commodity/3", decodeCommodity3)
+	registerSchema("journal/1", decodeJournal1)
+	registerSchema("outfitting/1", decodeOutfitting1)
+	registerSchema("outfitting/2", decodeOutfitting2)
+	registerSchema("blackmarket/1", decodeBlackmarket1)
+	registerSchema("shipyard/1", decodeShipyard1)
+	registerSchema("shipyard/2", decodeShipyard2)
+	registerSchema("approachsettlement/1", decodeApproachSettlement1)
+	registerSchema("navroute/1", decodeNavRoute1)
+	registerSchema("fsssignaldiscovered/1", decodeFSSSignalDiscovered1)
+	registerSchema("codexentry/1", decodeCodexEntry1)
+	registerSchema("fssbodysignals/1", decodeFSSBodySignals1)
+	registerSchema("scanbarycentre/1", decodeScanBaryCentre1)
+	registerSchema("fcmaterials_journal/1", decodeFCMaterialsJournal1)
+	registerSchema("fcmaterials_capi/1", decodeFCMaterialsCAPI1)
+
+	registerJournalEvent("FSDJump", decodeJournalFSDJump)
+	registerJournalEvent("Docked", decodeJournalDocked)
+	registerJournalEvent("Scan", decodeJournalScan)
+	registerJournalEvent("FSSDiscoveryScan", decodeJournalFSSDiscoveryScan)
+	registerJournalEvent("SAASignalsFound", decodeJournalSAASignalsFound)
+	registerJournalEvent("CarrierJump", decodeJournalCarrierJump)
+	registerJournalEvent("Location", decodeJournalLocation)
+	registerJournalEvent("NavBeaconScan", decodeJournalNavBeaconScan)
+	registerJournalEvent("Undocked", decodeJournalUndocked)
+}
+
+func decodeJournalFSDJump(journalMsg map[string]interface{},
+	options JournalDecodeOptions) (out interface{}, err error) {
+	var jumpMsg JournalFSDJump
+	err = decodeJournal(journalMsg, &jumpMsg, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return jumpMsg, nil
+}
+
+func decodeJournalDocked(journalMsg map[string]interface{},
+	options JournalDecodeOptions) (out interface{}, err error) {
+	var dockedMsg JournalDocked
+	err = decodeJournal(journalMsg, &dockedMsg, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return dockedMsg, nil
+}
+
+// scanKind is the kind of body described by a Scan event.
+type scanKind int
+
+const (
+	scanUnknown     scanKind = iota // Not identifiable, decoded as a planet
+	scanStar                        // JournalScanStar
+	scanPlanet                      // JournalScanPlanet
+	scanRing                        // JournalScanRing
+	scanBeltCluster                 // JournalScanBeltCluster
+)
+
+// classifyScan returns the kind of body described by a Scan event.  Stars
+// have a StarType, which takes precedence, and planets, and moons have a
+// PlanetClass.  Belt clusters, and rings have neither and can only be told
+// apart by their name.  The ScanType isn't used, as even autoscans include
+// the StarType, or PlanetClass of the body.
+func classifyScan(journalMsg map[string]interface{}) scanKind {
+	if _, ok := journalMsg["StarType"]; ok {
+		return scanStar
+	}
+
+	if _, ok := journalMsg["PlanetClass"]; ok {
+		return scanPlanet
+	}
+
+	bodyName, _ := journalMsg["BodyName"].(string)
+
+	switch {
+	case strings.Contains(bodyName, "Belt Cluster"):
+		return scanBeltCluster
+	case strings.HasSuffix(bodyName, " Ring"):
+		return scanRing
+	}
+
+	return scanUnknown
+}
+
+func decodeJournalScan(journalMsg map[string]interface{},
+	options JournalDecodeOptions) (out interface{}, err error) {
+	switch classifyScan(journalMsg) {
+	case scanStar:
+		var scanMsg JournalScanStar
+		err = decodeJournal(journalMsg, &scanMsg, options)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return scanMsg, nil
+
+	case scanBeltCluster:
+		var scanMsg JournalScanBeltCluster
+		err = decodeJournal(journalMsg, &scanMsg, options)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return scanMsg, nil
+
+	case scanRing:
+		var scanMsg JournalScanRing
+		err = decodeJournal(journalMsg, &scanMsg, options)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return scanMsg, nil
+	}
+
+	// We have a body, or at least nothing that says otherwise.
+	var scanMsg JournalScanPlanet
+	err = decodeJournal(journalMsg, &scanMsg, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanMsg, nil
 }
 
+func decodeJournalFSSDiscoveryScan(journalMsg map[string]interface{},
+	options JournalDecodeOptions) (out interface{}, err error) {
+	var scanMsg JournalFSSDiscoveryScan
+	err = decodeJournal(journalMsg, &scanMsg, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanMsg, nil
+}
+
+func decodeJournalSAASignalsFound(journalMsg map[string]interface{},
+	options JournalDecodeOptions) (out interface{}, err error) {
+	var signalsMsg JournalSAASignalsFound
+	err = decodeJournal(journalMsg, &signalsMsg, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return signalsMsg, nil
+}
+
+func decodeJournalCarrierJump(journalMsg map[string]interface{},
+	options JournalDecodeOptions) (out interface{}, err error) {
+	var jumpMsg JournalCarrierJump
+	err = decodeJournal(journalMsg, &jumpMsg, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return jumpMsg, nil
+}
+
+func decodeJournalLocation(journalMsg map[string]interface{},
+	options JournalDecodeOptions) (out interface{}, err error) {
+	var locationMsg JournalLocation
+	err = decodeJournal(journalMsg, &locationMsg, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return locationMsg, nil
+}
+
+func decodeJournalNavBeaconScan(journalMsg map[string]interface{},
+	options JournalDecodeOptions) (out interface{}, err error) {
+	var scanMsg JournalNavBeaconScan
+	err = decodeJournal(journalMsg, &scanMsg, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanMsg, nil
+}
+
+func decodeJournalUndocked(journalMsg map[string]interface{},
+	options JournalDecodeOptions) (out interface{}, err error) {
+	var undockedMsg JournalUndocked
+	err = decodeJournal(journalMsg, &undockedMsg, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return undockedMsg, nil
+}
+
+// decodeJournal decodes a journal message into out, which must be a pointer
+// to one of the journal event types, using options.
+func decodeJournal(journalMsg map[string]interface{}, out interface{},
+	options JournalDecodeOptions) (err error) {
+
+	config := &mapstructure.DecoderConfig{
+		DecodeHook:       starPosHook,
+		WeaklyTypedInput: options.WeaklyTypedInput,
+		Result:           out}
+
+	if options.OnUnused != nil {
+		config.Metadata = &mapstructure.Metadata{}
+	}
+
+	decoder, err := mapstructure.NewDecoder(config)
+
+	if err != nil {
+		return err
+	}
+
+	if err = decoder.Decode(journalMsg); err != nil {
+		return err
+	}
+
+	if config.Metadata != nil && len(config.Metadata.Unused) > 0 {
+		event, _ := journalMsg["event"].(string)
+		options.OnUnused(event, config.Metadata.Unused)
+	}
+
+	return nil
+}
+
+// handleJournalMessage decodes a journal message using the defaultParser.
 func handleJournalMessage(msg interface{}) (out interface{}, err error) {
+	return defaultParser.handleJournalMessage(msg)
+}
+
+// handleJournalMessage decodes a journal message into one of the journal
+// event types, or whatever its registered JournalEventDecoder returns.
+func (p *parser) handleJournalMessage(msg interface{}) (out interface{}, err error) {
+	journalMsg, ok := msg.(map[string]interface{})
+
+	if !ok {
+		return nil, errors.New("msg is not a Journal type")
+	}
 
-	if journalMsg, ok := msg.(map[string]interface{}); ok {
+	exactNumbers(journalMsg)
 
-		if event, ok := journalMsg["event"]; ok {
+	name, ok := journalMsg["event"].(string)
 
-			switch event {
-			case "FSDJump":
-				var jumpMsg JournalFSDJump
-				err := mapstructure.Decode(journalMsg, &jumpMsg)
+	if !ok {
+		return nil, &InvalidEventError{journalMsg["event"]}
+	}
+
+	if name == "Scan" && p.options.ScanFilter != nil &&
+		!p.options.ScanFilter(journalMsg) {
+		bodyName, _ := journalMsg["BodyName"].(string)
+		return nil, &ScanFilteredError{bodyName}
+	}
 
-				if err != nil {
-					return nil, err
-				}
+	decode, ok := journalEventDecoder(name)
 
-				return jumpMsg, nil
+	if !ok {
+		return nil, &UnhandledEventError{name}
+	}
 
-			case "Docked":
-				var dockedMsg JournalDocked
-				err := mapstructure.Decode(journalMsg, &dockedMsg)
+	out, err = decode(journalMsg, p.options.JournalDecode)
 
-				if err != nil {
-					return nil, err
-				}
+	if err != nil {
+		return nil, &JournalDecodeError{Event: name, Err: err}
+	}
 
-				return dockedMsg, nil
+	return out, nil
+}
 
-			case "Scan":
-				// Check if it's a star, or a body.
-				if _, ok := journalMsg["StarType"]; ok {
-					var scanMsg JournalScanStar
-					err := mapstructure.Decode(journalMsg, &scanMsg)
+// unmarshalMessage decodes the Message of root into v, disallowing unknown
+// fields if root was decoded with ParseOptions.Strict.
+func (root Root) unmarshalMessage(v interface{}) error {
+	if root.options != nil && root.options.Strict {
+		return unmarshalStrict(root.Message, v)
+	}
 
-					if err != nil {
-						return nil, err
-					}
+	return json.Unmarshal(root.Message, v)
+}
 
-					return scanMsg, nil
-				}
+// unmarshalStrict is json.Unmarshal, but returns an error for any field in
+// data that v doesn't have.
+func unmarshalStrict(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
 
-				// We have a body
-				var scanMsg JournalScanPlanet
-				err := mapstructure.Decode(journalMsg, &scanMsg)
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
 
-				if err != nil {
-					return nil, err
-				}
+	// Unlike json.Unmarshal, a Decoder stops at the end of the first value.
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("invalid data after top-level value")
+	}
 
-				return scanMsg, nil
+	return nil
+}
 
-			default:
-				return nil, errors.New("invalid event, or event not found")
-			}
+// maxExactInteger is the largest integer every smaller integer can be held
+// exactly by a float64.
+const maxExactInteger = 1 << 53
 
+// exactNumbers replaces the json.Number values in v, which was decoded with
+// UseNumber, with float64 values as encoding/json would normally decode them.
+// Integers too large to be held exactly by a float64, such as most
+// SystemAddress values, are kept as an int64 instead.  Maps, and slices are
+// updated in place.
+func exactNumbers(v interface{}) interface{} {
+	switch value := v.(type) {
+	case json.Number:
+		if n, err := value.Int64(); err == nil &&
+			(n > maxExactInteger || n < -maxExactInteger) {
+			return n
 		}
 
+		f, _ := value.Float64()
+
+		return f
+
+	case map[string]interface{}:
+		for key, element := range value {
+			value[key] = exactNumbers(element)
+		}
+
+	case []interface{}:
+		for i, element := range value {
+			value[i] = exactNumbers(element)
+		}
+	}
+
+	return v
+}
+
+// isJSONArray reports whether the JSON value data is an array.
+func isJSONArray(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+
+	return len(data) > 0 && data[0] == '['
+}
+
+// isZlib reports whether data begins with a valid zlib header.  The header
+// is two bytes where the low nibble of the first is the deflate method (8),
+// and the pair read as a big endian integer is a multiple of 31.
+func isZlib(data []byte) bool {
+	if len(data) < 2 {
+		return false
 	}
 
-	return nil, errors.New("msg is not a Journal type")
+	return data[0]&0x0f == 8 && (uint16(data[0])<<8|uint16(data[1]))%31 == 0
 }
 
-func parseJSON(data string) (parsed interface{}, err error) {
-	r, _ := zlib.NewReader(strings.NewReader(data))
+// isGzip reports whether data begins with the gzip magic number.
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+// zlibReaders pools the zlib readers used by parseJSON so one isn't allocated
+// for every message.
+var zlibReaders sync.Pool
+
+// getZlibReader returns a zlib reader from the pool reading from src, or a
+// new one if the pool is empty.  The reader must be returned with
+// putZlibReader, even if err isn't nil.
+func getZlibReader(src io.Reader) (r io.ReadCloser, err error) {
+	if r, ok := zlibReaders.Get().(io.ReadCloser); ok {
+		return r, r.(zlib.Resetter).Reset(src, nil)
+	}
+
+	return zlib.NewReader(src)
+}
+
+// putZlibReader closes r, and returns it to the pool.  r may be nil.
+func putZlibReader(r io.ReadCloser) {
+	if r == nil {
+		return
+	}
+
+	r.Close()
+	zlibReaders.Put(r)
+}
+
+// ParseMessage parses a single message obtained from EDDN, or elsewhere, and
+// returns its native Go type along with the schema reference and header.
+// data may be either zlib compressed as it is on the wire, gzip compressed,
+// or plain JSON.  The Message of the result will be one of the high level
+// message types such as Commodity, Journal, or Shipyard and must be asserted
+// by the caller.  Messages using a schema that isn't supported return an
+// *UnsupportedSchemaError, and compressed messages larger than
+// DefaultMaxMessageSize once decompressed return a *MessageTooLargeError.
+func ParseMessage(data []byte) (result ParseResult, err error) {
+	return parseJSON(string(data))
+}
+
+// ParseMessageWithOptions is the same as ParseMessage, but allows the
+// receiver to provide ParseOptions.
+func ParseMessageWithOptions(data []byte,
+	options ParseOptions) (result ParseResult, err error) {
+	p := &parser{options: options}
+
+	return p.parseJSON(string(data))
+}
+
+// ParseBase64 is the same as ParseMessage, but for a frame that has been
+// base64 encoded by whatever relayed it.  i.e. a webhook, or message queue.
+// The frame is decoded before being decompressed, and parsed as normal.
+func ParseBase64(s string) (result ParseResult, err error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+
+	if err != nil {
+		return ParseResult{}, fmt.Errorf("decoding base64 message: %w", err)
+	}
+
+	return ParseMessage(data)
+}
+
+// ParseJournalEvent decodes a single journal event, such as a line of the
+// game's own journal files, into one of the journal event types without it
+// being wrapped in an EDDN message.  The event is decoded exactly as the
+// Message of a Journal would be, including by any JournalEventDecoder
+// registered by the receiver.  Events that aren't handled return an *UnhandledEventError, and those that fail
+// to decode a *JournalDecodeError.
+//
+// m should be decoded with json.Decoder.UseNumber, as a float64 can't hold
+// every SystemAddress exactly.  The json.Number values in m are replaced with
+// a float64, or an int64 for integers larger than 2^53, as described by
+// JournalEventDecoder.
+func ParseJournalEvent(m map[string]interface{}) (interface{}, error) {
+	return handleJournalMessage(m)
+}
+
+// ParseJournalEventWithOptions is the same as ParseJournalEvent, but allows
+// the receiver to provide ParseOptions.  Only the options concerning journal
+// events apply.
+func ParseJournalEventWithOptions(m map[string]interface{},
+	options ParseOptions) (interface{}, error) {
+
+	p := &parser{options: options}
+
+	return p.handleJournalMessage(m)
+}
+
+// MessageTooLargeError is returned when a message is larger than the maximum
+// message size once it's decompressed.
+type MessageTooLargeError struct {
+	Limit int64 // Maximum size of a decompressed message in bytes
+}
+
+func (e *MessageTooLargeError) Error() string {
+	return fmt.Sprintf("message larger than %d bytes once decompressed", e.Limit)
+}
+
+// parser holds the options used while parsing messages.  The zero value
+// parses every message.
+type parser struct {
+	// accept is called with the Root of each message before it's decoded.
+	// Messages it returns false for are discarded with errFiltered, which
+	// saves decoding messages the receiver isn't interested in.
+	accept func(root Root) bool
+
+	// frame, when set, is called with each decompressed message once it has
+	// been parsed, whether or not it was accepted, or parsed successfully.
+	frame func(output []byte)
+
+	options ParseOptions
+}
+
+// defaultParser is used by ParseMessage, and parseJSON.
+var defaultParser = &parser{}
+
+// parseJSON parses a message received from EDDN using the defaultParser.
+func parseJSON(data string) (result ParseResult, err error) {
+	return defaultParser.parseJSON(data)
+}
+
+// parseJSONRaw parses an already decompressed message from EDDN using the
+// defaultParser.
+func parseJSONRaw(output []byte) (result ParseResult, err error) {
+	return defaultParser.parseJSONRaw(output)
+}
+
+// parseJSON parses a message received from EDDN.  The data is decompressed
+// first if it's zlib, or gzip compressed, otherwise it's treated as plain
+// JSON.  Anything a relay appends after a zlib stream is ignored, as the
+// reader stops at the end of the stream's checksum.
+func (p *parser) parseJSON(data string) (result ParseResult, err error) {
+	if p.options.Timing {
+		start := time.Now()
+		defer func() { result.Duration = time.Since(start) }()
+	}
+
+	if isGzip([]byte(data)) {
+		return p.parseGzip(data)
+	}
+
+	if !isZlib([]byte(data)) {
+		return p.parseJSONRaw([]byte(data))
+	}
+
+	r, err := getZlibReader(strings.NewReader(data))
+
+	// Every path from here closes r exactly once by returning it to the
+	// pool.  This includes the nil reader returned for an invalid header,
+	// which putZlibReader ignores.
+	defer putZlibReader(r)
+
+	if err != nil {
+		err = &categoryError{ErrDecompress, err}
+		logf("Error: %v", err)
+		return ParseResult{}, err
+	}
+
+	output, err := p.decompress(r, len(data))
+
+	if err != nil {
+		return ParseResult{}, err
+	}
+
+	return p.parseJSONRaw(output)
+}
+
+// parseGzip parses a gzip compressed message.  EDDN itself only uses zlib,
+// but archives of messages are often gzip compressed instead.
+func (p *parser) parseGzip(data string) (result ParseResult, err error) {
+	r, err := gzip.NewReader(strings.NewReader(data))
+
+	if err != nil {
+		err = &categoryError{ErrDecompress, err}
+		logf("Error: %v", err)
+		return ParseResult{}, err
+	}
+
 	defer r.Close()
 
-	output, err := ioutil.ReadAll(r)
+	output, err := p.decompress(r, len(data))
 
 	if err != nil {
-		fmt.Printf("Error: %v", err)
+		return ParseResult{}, err
+	}
+
+	return p.parseJSONRaw(output)
+}
+
+// compressionRatio is roughly how much smaller messages from EDDN are once
+// compressed.  It's used to size the buffer a message is decompressed into
+// so it rarely needs to grow.
+const compressionRatio = 8
+
+// decompress reads the entire message from r, returning a
+// *MessageTooLargeError if it's larger than the MaxMessageSize of p.
+// compressedSize is the size of the message before it's decompressed.
+func (p *parser) decompress(r io.Reader, compressedSize int) (output []byte, err error) {
+	maxSize := p.options.MaxMessageSize
+
+	if maxSize <= 0 {
+		maxSize = DefaultMaxMessageSize
+	}
+
+	size := int64(compressedSize) * compressionRatio
+
+	if size > maxSize {
+		size = maxSize
+	}
+
+	// Read one byte past the limit to tell if the message was too large.
+	// This is io.ReadAll, but starting with a buffer of the expected size
+	// rather than growing one from 512 bytes.  The extra byte leaves room
+	// for the read that finds the end of the message.
+	r = io.LimitReader(r, maxSize+1)
+	output = make([]byte, 0, size+1)
+
+	for {
+		if len(output) == cap(output) {
+			output = append(output, 0)[:len(output)]
+		}
+
+		var n int
+		n, err = r.Read(output[len(output):cap(output)])
+		output = output[:len(output)+n]
+
+		if err == io.EOF {
+			err = nil
+			break
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	if err != nil {
+		err = &categoryError{ErrDecompress, err}
+		logf("Error: %v", err)
+		return nil, err
+	}
+
+	if int64(len(output)) > maxSize {
+		err = &MessageTooLargeError{maxSize}
+		logf("Error: %v", err)
 		return nil, err
 	}
 
+	return output, nil
+}
+
+// parseJSONRaw parses an already decompressed message from EDDN.
+func (p *parser) parseJSONRaw(output []byte) (result ParseResult, err error) {
+	if p.frame != nil {
+		defer p.frame(output)
+	}
+
 	// Parse the schema to find out what kind of message we're going to be
 	// handling.
 	var jsonData Root
 
-	err = json.Unmarshal(output, &jsonData)
+	if p.options.Strict {
+		err = unmarshalStrict(output, &jsonData)
+	} else {
+		err = json.Unmarshal(output, &jsonData)
+	}
+
+	jsonData.options = &p.options
 
 	if err != nil {
-		fmt.Println("Error: ", err)
+		err = &categoryError{ErrJSON, err}
+		logf("Error: %v", err)
+		return ParseResult{}, err
+	}
+
+	if p.accept != nil && !p.accept(jsonData) {
+		return ParseResult{}, errFiltered
+	}
+
+	result.IsTest = IsTestSchema(jsonData.SchemaRef)
+
+	// Test messages are disregarded unless asked for.
+	if result.IsTest && !p.options.IncludeTest {
+		return ParseResult{}, &UnsupportedSchemaError{jsonData.SchemaRef}
+	}
+
+	if p.options.Validate {
+		if err := checkRequiredFields(jsonData); err != nil {
+			logf("Error: %v", err)
+			return ParseResult{}, err
+		}
+	}
+
+	result.SchemaRef = jsonData.SchemaRef
+	result.Header = jsonData.Header
+	if p.options.Timing {
+		start := time.Now()
+		result.Message, err = decodeMessage(jsonData, output)
+		result.DecodeDuration = time.Since(start)
+	} else {
+		result.Message, err = decodeMessage(jsonData, output)
+	}
+
+	if p.options.IncludeRaw {
+		result.Raw = output
+	}
+
+	var unsupported *UnsupportedSchemaError
+
+	if errors.As(err, &unsupported) && p.options.DeliverUnknown {
+		result.Message = RawUnknown{
+			SchemaRef: jsonData.SchemaRef,
+			Header:    jsonData.Header,
+			Message:   jsonData.Message}
+
+		return result, nil
+	}
+
+	if err != nil {
+		return ParseResult{}, err
+	}
+
+	return result, nil
+}
+
+// decodeMessage decodes output into the type described by the schema found
+// in jsonData.
+func decodeMessage(jsonData Root, output []byte) (parsed interface{}, err error) {
+	name, version, err := schemaKey(jsonData.SchemaRef)
+
+	if err != nil {
+		return nil, err
+	}
+
+	decode, ok := schemaDecoder(name, version)
+
+	if !ok {
+		return nil, &UnsupportedSchemaError{jsonData.SchemaRef}
+	}
+
+	if isJSONArray(jsonData.Message) {
+		return nil, &BatchMessageError{jsonData.SchemaRef}
+	}
+
+	parsed, err = decode(jsonData, output)
+
+	if err != nil {
+		return nil, &DecodeError{SchemaRef: jsonData.SchemaRef, Err: err}
+	}
+
+	return parsed, nil
+}
+
+func decodeCommodity1(root Root, raw []byte) (parsed interface{}, err error) {
+	commodityData := Commodity1{SchemaRef: root.SchemaRef, Header: root.Header}
+
+	if err := root.unmarshalMessage(&commodityData.Message); err != nil {
 		return nil, err
 	}
 
-	switch jsonData.SchemaRef {
-	case "http://schemas.elite-markets.net/eddn/commodity/1":
-		fallthrough
-	case "http://schemas.elite-markets.net/eddn/commodity/2":
-		err := errors.New("commodity versions 1 and 2 not currently supported")
+	return commodityData.Commodity(), nil
+}
+
+func decodeCommodity2(root Root, raw []byte) (parsed interface{}, err error) {
+	commodityData := Commodity2{SchemaRef: root.SchemaRef, Header: root.Header}
+
+	if err := root.unmarshalMessage(&commodityData.Message); err != nil {
 		return nil, err
+	}
 
-	case "http://schemas.elite-markets.net/eddn/commodity/3":
-		var commodityData Commodity
-		json.Unmarshal(output, &commodityData)
-		return commodityData, nil
+	return commodityData.Commodity(), nil
+}
 
-	case "http://schemas.elite-markets.net/eddn/journal/1":
-		var journalData Journal
-		json.Unmarshal(output, &journalData)
+// maxPreallocatedCommodities is the most commodities decodeCommodity3
+// allocates room for before decoding a market.  The largest markets list
+// about 400, and larger ones simply grow the slice as it's decoded.
+const maxPreallocatedCommodities = 512
 
-		parsedMsg, err := handleJournalMessage(journalData.Message)
+func decodeCommodity3(root Root, raw []byte) (parsed interface{}, err error) {
+	commodityData := Commodity{SchemaRef: root.SchemaRef, Header: root.Header}
 
-		if err != nil {
-			return nil, err
+	// Markets are the bulk of the traffic on EDDN, and often list over a
+	// hundred commodities.  Every commodity is an object, so counting them
+	// allows the slice to be allocated once rather than growing while it's
+	// decoded.  Economies, and braces inside strings are counted too, so
+	// the count is clamped to the size of the largest market rather than
+	// trusting a message to be honest.
+	if n := bytes.Count(root.Message, []byte("{")) - 1; n > 0 {
+		if n > maxPreallocatedCommodities {
+			n = maxPreallocatedCommodities
 		}
 
-		journalData.Message = parsedMsg
+		commodityData.Message.Commodities = make([]Commodities, 0, n)
+	}
+
+	if err := root.unmarshalMessage(&commodityData.Message); err != nil {
+		return nil, err
+	}
+
+	return commodityData, nil
+}
+
+func decodeJournal1(root Root, raw []byte) (parsed interface{}, err error) {
+	journalData := Journal{SchemaRef: root.SchemaRef, Header: root.Header}
+
+	// Numbers are kept as json.Number so large integers such as the
+	// SystemAddress aren't rounded by float64.  See exactNumbers.
+	decoder := json.NewDecoder(bytes.NewReader(root.Message))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&journalData.Message); err != nil {
+		return nil, err
+	}
+
+	p := defaultParser
+
+	if root.options != nil {
+		p = &parser{options: *root.options}
+	}
+
+	parsedMsg, err := p.handleJournalMessage(journalData.Message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	journalData.Message = parsedMsg
+
+	return journalData, nil
+}
+
+func decodeOutfitting1(root Root, raw []byte) (parsed interface{}, err error) {
+	outfittingData := Outfitting1{SchemaRef: root.SchemaRef, Header: root.Header}
+
+	if err := root.unmarshalMessage(&outfittingData.Message); err != nil {
+		return nil, err
+	}
+
+	return outfittingData, nil
+}
+
+func decodeOutfitting2(root Root, raw []byte) (parsed interface{}, err error) {
+	outfittingData := Outfitting{SchemaRef: root.SchemaRef, Header: root.Header}
+
+	if err := root.unmarshalMessage(&outfittingData.Message); err != nil {
+		return nil, err
+	}
+
+	return outfittingData, nil
+}
 
-		return journalData, nil
+func decodeBlackmarket1(root Root, raw []byte) (parsed interface{}, err error) {
+	blackmarketData := Blackmarket{SchemaRef: root.SchemaRef, Header: root.Header}
 
-	case "http://schemas.elite-markets.net/eddn/outfitting/1":
-		err := errors.New("outfitting version 1 is not currently supported")
+	if err := root.unmarshalMessage(&blackmarketData.Message); err != nil {
 		return nil, err
+	}
 
-	case "http://schemas.elite-markets.net/eddn/outfitting/2":
-		var outfittingData Outfitting
-		json.Unmarshal(output, &outfittingData)
-		return outfittingData, nil
+	return blackmarketData, nil
+}
 
-	case "http://schemas.elite-markets.net/eddn/blackmarket/1":
-		var blackmarketData Blackmarket
-		json.Unmarshal(output, &blackmarketData)
-		return blackmarketData, nil
+func decodeShipyard1(root Root, raw []byte) (parsed interface{}, err error) {
+	shipyardData := Shipyard1{SchemaRef: root.SchemaRef, Header: root.Header}
 
-	case "http://schemas.elite-markets.net/eddn/shipyard/1":
-		err := errors.New("shipyard version 1 is not currently supported")
+	if err := root.unmarshalMessage(&shipyardData.Message); err != nil {
 		return nil, err
+	}
 
-	case "http://schemas.elite-markets.net/eddn/shipyard/2":
-		var shipyardData Shipyard
-		json.Unmarshal(output, &shipyardData)
-		return shipyardData, nil
+	return shipyardData, nil
+}
 
-		// Handle special cases with test.  Disregard these.
-	case "http://schemas.elite-markets.net/eddn/shipyard/2/test":
-		fallthrough
-	case "http://schemas.elite-markets.net/eddn/blackmarket/1/test":
-		fallthrough
-	case "http://schemas.elite-markets.net/eddn/outfitting/2/test":
-		fallthrough
-	case "http://schemas.elite-markets.net/eddn/journal/1/test":
-		fallthrough
-	case "http://schemas.elite-markets.net/eddn/commodity/3/test":
-		fallthrough
+func decodeShipyard2(root Root, raw []byte) (parsed interface{}, err error) {
+	shipyardData := Shipyard{SchemaRef: root.SchemaRef, Header: root.Header}
 
-	default:
-		return nil, errUnhandledSchema
+	if err := root.unmarshalMessage(&shipyardData.Message); err != nil {
+		return nil, err
+	}
+
+	return shipyardData, nil
+}
+
+func decodeApproachSettlement1(root Root, raw []byte) (parsed interface{}, err error) {
+	settlementData := ApproachSettlement{SchemaRef: root.SchemaRef, Header: root.Header}
+
+	if err := root.unmarshalMessage(&settlementData.Message); err != nil {
+		return nil, err
+	}
+
+	return settlementData, nil
+}
+
+func decodeNavRoute1(root Root, raw []byte) (parsed interface{}, err error) {
+	routeData := NavRoute{SchemaRef: root.SchemaRef, Header: root.Header}
+
+	if err := root.unmarshalMessage(&routeData.Message); err != nil {
+		return nil, err
+	}
+
+	return routeData, nil
+}
+
+func decodeFSSSignalDiscovered1(root Root, raw []byte) (parsed interface{}, err error) {
+	signalData := FSSSignalDiscovered{SchemaRef: root.SchemaRef, Header: root.Header}
+
+	if err := root.unmarshalMessage(&signalData.Message); err != nil {
+		return nil, err
+	}
+
+	return signalData, nil
+}
+
+func decodeCodexEntry1(root Root, raw []byte) (parsed interface{}, err error) {
+	codexData := CodexEntry{SchemaRef: root.SchemaRef, Header: root.Header}
+
+	if err := root.unmarshalMessage(&codexData.Message); err != nil {
+		return nil, err
+	}
+
+	return codexData, nil
+}
+
+func decodeFSSBodySignals1(root Root, raw []byte) (parsed interface{}, err error) {
+	signalsData := FSSBodySignals{SchemaRef: root.SchemaRef, Header: root.Header}
+
+	if err := root.unmarshalMessage(&signalsData.Message); err != nil {
+		return nil, err
+	}
+
+	return signalsData, nil
+}
+
+func decodeScanBaryCentre1(root Root, raw []byte) (parsed interface{}, err error) {
+	baryCentreData := ScanBaryCentre{SchemaRef: root.SchemaRef, Header: root.Header}
+
+	if err := root.unmarshalMessage(&baryCentreData.Message); err != nil {
+		return nil, err
+	}
+
+	return baryCentreData, nil
+}
+
+func decodeFCMaterialsJournal1(root Root, raw []byte) (parsed interface{}, err error) {
+	return decodeFCMaterials(root, FCMaterialsJournal)
+}
+
+func decodeFCMaterialsCAPI1(root Root, raw []byte) (parsed interface{}, err error) {
+	return decodeFCMaterials(root, FCMaterialsCAPI)
+}
+
+// decodeFCMaterials decodes either of the fcmaterials schemas, which only
+// differ in where the materials were read from.
+func decodeFCMaterials(root Root, source FCMaterialsSource) (parsed interface{}, err error) {
+	materialsData := FCMaterials{SchemaRef: root.SchemaRef, Header: root.Header,
+		Source: source}
+
+	if err := root.unmarshalMessage(&materialsData.Message); err != nil {
+		return nil, err
 	}
 
+	return materialsData, nil
 }
@@ -0,0 +1,1545 @@
+package EDDNClient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// compress zlib compresses the given JSON the same way EDDN does on the wire.
+func compress(t testing.TB, data string) string {
+	var buf bytes.Buffer
+
+	w := zlib.NewWriter(&buf)
+
+	if _, err := w.Write([]byte(data)); err != nil {
+		t.Fatalf("Error compressing fixture: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Error compressing fixture: %v", err)
+	}
+
+	return buf.String()
+}
+
+const commodity1Fixture = `{
+	"$schemaRef": "http://schemas.elite-markets.net/eddn/commodity/1",
+	"header": {
+		"uploaderID": "abcdef0123456789",
+		"softwareName": "My Awesome Market Uploader",
+		"softwareVersion": "v3.14"
+	},
+	"message": {
+		"systemName": "Eranin",
+		"stationName": "Azeban Orbital",
+		"itemName": "Gold",
+		"buyPrice": 9000,
+		"stationStock": 125,
+		"supplyLevel": "Med",
+		"sellPrice": 8900,
+		"demand": 3,
+		"demandLevel": "Low",
+		"timestamp": "2014-11-17T12:34:56+00:00"
+	}
+}`
+
+const commodity2Fixture = `{
+	"$schemaRef": "http://schemas.elite-markets.net/eddn/commodity/2",
+	"header": {
+		"uploaderID": "abcdef0123456789",
+		"softwareName": "My Awesome Market Uploader",
+		"softwareVersion": "v3.14"
+	},
+	"message": {
+		"systemName": "Eranin",
+		"stationName": "Azeban Orbital",
+		"timestamp": "2015-06-01T12:34:56+00:00",
+		"commodities": [
+			{
+				"name": "Gold",
+				"buyPrice": 9000,
+				"supply": 125,
+				"supplyLevel": "High",
+				"sellPrice": 8900,
+				"demand": 0
+			},
+			{
+				"name": "Explosives",
+				"buyPrice": 0,
+				"supply": 0,
+				"sellPrice": 312,
+				"demand": 4500,
+				"demandLevel": "Med"
+			}
+		]
+	}
+}`
+
+func TestParseCommodity1(t *testing.T) {
+	result, err := parseJSON(compress(t, commodity1Fixture))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	commodity, ok := result.Message.(Commodity)
+
+	if !ok {
+		t.Fatalf("Expected Commodity, got %T", result.Message)
+	}
+
+	if commodity.SchemaRef != "http://schemas.elite-markets.net/eddn/commodity/1" {
+		t.Errorf("Unexpected schema: %s", commodity.SchemaRef)
+	}
+
+	msg := commodity.Message
+
+	if msg.SystemName != "Eranin" || msg.StationName != "Azeban Orbital" ||
+		msg.Timestamp != "2014-11-17T12:34:56+00:00" {
+		t.Errorf("Unexpected station data: %+v", msg)
+	}
+
+	if len(msg.Commodities) != 1 {
+		t.Fatalf("Expected 1 commodity, got %d", len(msg.Commodities))
+	}
+
+	expected := Commodities{BuyPrice: 9000, Demand: 3, DemandBracket: 1,
+		Name: "Gold", SellPrice: 8900, Stock: 125, StockBracket: 2}
+
+	if got := msg.Commodities[0]; got.Name != expected.Name ||
+		got.BuyPrice != expected.BuyPrice || got.Demand != expected.Demand ||
+		got.DemandBracket != expected.DemandBracket ||
+		got.SellPrice != expected.SellPrice || got.Stock != expected.Stock ||
+		got.StockBracket != expected.StockBracket {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestParseCommodity2(t *testing.T) {
+	result, err := parseJSON(compress(t, commodity2Fixture))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.SchemaRef != "http://schemas.elite-markets.net/eddn/commodity/2" {
+		t.Errorf("Unexpected schema: %s", result.SchemaRef)
+	}
+
+	if result.Header.UploaderID != "abcdef0123456789" ||
+		result.Header.SoftwareName != "My Awesome Market Uploader" ||
+		result.Header.SoftwareVersion != "v3.14" {
+		t.Errorf("Unexpected result header: %+v", result.Header)
+	}
+
+	commodity, ok := result.Message.(Commodity)
+
+	if !ok {
+		t.Fatalf("Expected Commodity, got %T", result.Message)
+	}
+
+	if commodity.Header.UploaderID != "abcdef0123456789" {
+		t.Errorf("Unexpected header: %+v", commodity.Header)
+	}
+
+	commodities := commodity.Message.Commodities
+
+	if len(commodities) != 2 {
+		t.Fatalf("Expected 2 commodities, got %d", len(commodities))
+	}
+
+	if commodities[0].Name != "Gold" || commodities[0].Stock != 125 ||
+		commodities[0].StockBracket != 3 || commodities[0].DemandBracket != 0 {
+		t.Errorf("Unexpected first commodity: %+v", commodities[0])
+	}
+
+	if commodities[1].Name != "Explosives" || commodities[1].Demand != 4500 ||
+		commodities[1].DemandBracket != 2 || commodities[1].StockBracket != 0 {
+		t.Errorf("Unexpected second commodity: %+v", commodities[1])
+	}
+}
+
+const outfitting1Fixture = `{
+	"$schemaRef": "http://schemas.elite-markets.net/eddn/outfitting/1",
+	"header": {
+		"uploaderID": "abcdef0123456789",
+		"softwareName": "My Awesome Outfitting Uploader",
+		"softwareVersion": "v3.14"
+	},
+	"message": {
+		"systemName": "Eranin",
+		"stationName": "Azeban Orbital",
+		"timestamp": "2015-06-01T12:34:56+00:00",
+		"modules": [
+			{
+				"category": "weapon",
+				"name": "Pulse Laser",
+				"mount": "Gimballed",
+				"class": "1",
+				"rating": "G"
+			},
+			{
+				"category": "standard",
+				"name": "Frame Shift Drive",
+				"class": "5",
+				"rating": "A",
+				"ship": "Cobra Mk III"
+			}
+		]
+	}
+}`
+
+func TestParseOutfitting1(t *testing.T) {
+	result, err := parseJSON(compress(t, outfitting1Fixture))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	outfitting, ok := result.Message.(Outfitting1)
+
+	if !ok {
+		t.Fatalf("Expected Outfitting1, got %T", result.Message)
+	}
+
+	modules := outfitting.Message.Modules
+
+	if len(modules) != 2 {
+		t.Fatalf("Expected 2 modules, got %d", len(modules))
+	}
+
+	if modules[0].Category != "weapon" || modules[0].Mount != "Gimballed" ||
+		modules[0].Class != "1" || modules[0].Rating != "G" {
+		t.Errorf("Unexpected first module: %+v", modules[0])
+	}
+
+	if modules[1].Ship != "Cobra Mk III" || modules[1].Mount != "" {
+		t.Errorf("Unexpected second module: %+v", modules[1])
+	}
+
+	converted := outfitting.Outfitting()
+
+	if converted.Message.SystemName != "Eranin" ||
+		converted.Message.StationName != "Azeban Orbital" {
+		t.Errorf("Unexpected station data: %+v", converted.Message)
+	}
+
+	if len(converted.Message.Modules) != 2 ||
+		converted.Message.Modules[0] != "Pulse Laser" ||
+		converted.Message.Modules[1] != "Frame Shift Drive" {
+		t.Errorf("Unexpected modules: %v", converted.Message.Modules)
+	}
+}
+
+const shipyard1Fixture = `{
+	"$schemaRef": "http://schemas.elite-markets.net/eddn/shipyard/1",
+	"header": {
+		"uploaderID": "abcdef0123456789",
+		"softwareName": "My Awesome Shipyard Uploader",
+		"softwareVersion": "v3.14"
+	},
+	"message": {
+		"systemName": "Eranin",
+		"stationName": "Azeban Orbital",
+		"timestamp": "2015-06-01T12:34:56+00:00",
+		"ships": [
+			{"name": "Sidewinder", "price": 32000},
+			{"name": "Cobra Mk III", "price": 379718}
+		]
+	}
+}`
+
+const shipyard1NoStationFixture = `{
+	"$schemaRef": "http://schemas.elite-markets.net/eddn/shipyard/1",
+	"header": {
+		"uploaderID": "abcdef0123456789",
+		"softwareName": "My Awesome Shipyard Uploader",
+		"softwareVersion": "v3.14"
+	},
+	"message": {
+		"systemName": "Eranin",
+		"timestamp": "2015-06-01T12:34:56+00:00",
+		"ships": [
+			{"name": "Hauler"}
+		]
+	}
+}`
+
+func TestParseShipyard1Prices(t *testing.T) {
+	result, err := parseJSON(compress(t, shipyard1Fixture))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	shipyard, ok := result.Message.(Shipyard1)
+
+	if !ok {
+		t.Fatalf("Expected Shipyard1, got %T", result.Message)
+	}
+
+	ships := shipyard.Message.Ships
+
+	if len(ships) != 2 || ships[0].Price != 32000 || ships[1].Price != 379718 {
+		t.Errorf("Unexpected ships: %+v", ships)
+	}
+
+	converted := shipyard.Shipyard()
+
+	if len(converted.Message.Ships) != 2 ||
+		converted.Message.Ships[0] != "Sidewinder" ||
+		converted.Message.Ships[1] != "Cobra Mk III" {
+		t.Errorf("Unexpected converted ships: %v", converted.Message.Ships)
+	}
+
+	if converted.Message.StationName != "Azeban Orbital" {
+		t.Errorf("Unexpected station: %s", converted.Message.StationName)
+	}
+}
+
+func TestParseShipyard1NoStation(t *testing.T) {
+	result, err := parseJSON(compress(t, shipyard1NoStationFixture))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	shipyard, ok := result.Message.(Shipyard1)
+
+	if !ok {
+		t.Fatalf("Expected Shipyard1, got %T", result.Message)
+	}
+
+	converted := shipyard.Shipyard()
+
+	if converted.Message.StationName != "" ||
+		converted.Message.SystemName != "Eranin" {
+		t.Errorf("Unexpected station data: %+v", converted.Message)
+	}
+
+	if len(converted.Message.Ships) != 1 ||
+		converted.Message.Ships[0] != "Hauler" {
+		t.Errorf("Unexpected converted ships: %v", converted.Message.Ships)
+	}
+}
+
+const approachSettlementFixture = `{
+	"$schemaRef": "https://eddn.edcd.io/schemas/approachsettlement/1",
+	"header": {
+		"uploaderID": "abcdef0123456789",
+		"softwareName": "E:D Market Connector [Windows]",
+		"softwareVersion": "5.1.1",
+		"gatewayTimestamp": "2021-05-25T18:20:31.492Z"
+	},
+	"message": {
+		"timestamp": "2021-05-25T18:20:30Z",
+		"event": "ApproachSettlement",
+		"Name": "Laurens Hub",
+		"MarketID": 3822487552,
+		"SystemAddress": 2862335682961,
+		"BodyID": 12,
+		"BodyName": "Pleione 3 a",
+		"Latitude": -12.25,
+		"Longitude": 63.75,
+		"StarSystem": "Pleione",
+		"StarPos": [-77.0, -146.78125, -344.125]
+	}
+}`
+
+func TestParseApproachSettlement(t *testing.T) {
+	result, err := parseJSON(compress(t, approachSettlementFixture))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	settlement, ok := result.Message.(ApproachSettlement)
+
+	if !ok {
+		t.Fatalf("Expected ApproachSettlement, got %T", result.Message)
+	}
+
+	msg := settlement.Message
+
+	if msg.Name != "Laurens Hub" || msg.MarketID != 3822487552 ||
+		msg.BodyName != "Pleione 3 a" || msg.StarPos.Z != -344.125 {
+		t.Errorf("Unexpected settlement: %+v", msg)
+	}
+
+	if msg.Latitude == nil || *msg.Latitude != -12.25 ||
+		msg.Longitude == nil || *msg.Longitude != 63.75 {
+		t.Errorf("Unexpected coordinates: %v, %v", msg.Latitude, msg.Longitude)
+	}
+}
+
+func TestParseApproachSettlementOrbital(t *testing.T) {
+	fixture := strings.Replace(approachSettlementFixture, `"Latitude": -12.25,
+		"Longitude": 63.75,`, "", 1)
+
+	result, err := parseJSON(fixture)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	msg := result.Message.(ApproachSettlement).Message
+
+	if msg.Latitude != nil || msg.Longitude != nil {
+		t.Errorf("Expected no coordinates, got %v, %v", msg.Latitude, msg.Longitude)
+	}
+}
+
+const navRouteFixture = `{
+	"$schemaRef": "https://eddn.edcd.io/schemas/navroute/1",
+	"header": {
+		"uploaderID": "abcdef0123456789",
+		"softwareName": "E:D Market Connector [Windows]",
+		"softwareVersion": "5.1.1"
+	},
+	"message": {
+		"timestamp": "2021-05-25T18:30:02Z",
+		"event": "NavRoute",
+		"Route": [
+			{
+				"StarSystem": "Pleione",
+				"SystemAddress": 2862335682961,
+				"StarPos": [-77.0, -146.78125, -344.125],
+				"StarClass": "B"
+			},
+			{
+				"StarSystem": "Merope",
+				"SystemAddress": 224644818084,
+				"StarPos": [-78.59375, -149.625, -340.53125],
+				"StarClass": "B"
+			},
+			{
+				"StarSystem": "Maia",
+				"SystemAddress": 81973396946,
+				"StarPos": [-81.78125, -149.4375, -343.375],
+				"StarClass": "B"
+			}
+		]
+	}
+}`
+
+func TestParseNavRoute(t *testing.T) {
+	result, err := parseJSON(compress(t, navRouteFixture))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	route, ok := result.Message.(NavRoute)
+
+	if !ok {
+		t.Fatalf("Expected NavRoute, got %T", result.Message)
+	}
+
+	hops := route.Message.Route
+
+	if len(hops) != 3 {
+		t.Fatalf("Expected 3 systems, got %d", len(hops))
+	}
+
+	if hops[0].StarSystem != "Pleione" || hops[2].StarSystem != "Maia" ||
+		hops[1].SystemAddress != 224644818084 || hops[1].StarClass != "B" ||
+		hops[2].StarPos != (StarPos{-81.78125, -149.4375, -343.375}) {
+		t.Errorf("Unexpected route: %+v", hops)
+	}
+}
+
+const fssSignalDiscoveredFixture = `{
+	"$schemaRef": "https://eddn.edcd.io/schemas/fsssignaldiscovered/1",
+	"header": {
+		"uploaderID": "abcdef0123456789",
+		"softwareName": "E:D Market Connector [Windows]",
+		"softwareVersion": "5.1.1"
+	},
+	"message": {
+		"timestamp": "2021-05-25T18:40:11Z",
+		"event": "FSSSignalDiscovered",
+		"SystemAddress": 2862335682961,
+		"StarSystem": "Pleione",
+		"StarPos": [-77.0, -146.78125, -344.125],
+		"signals": [
+			{
+				"timestamp": "2021-05-25T18:40:10Z",
+				"SignalName": "PLEIONE ORBITAL K7Q-BQL",
+				"SignalType": "FleetCarrier",
+				"IsStation": true
+			},
+			{
+				"timestamp": "2021-05-25T18:40:11Z",
+				"SignalName": "$USS_NonHumanSignalSource;",
+				"SignalType": "USS",
+				"USSType": "$USS_Type_NonHuman;",
+				"SpawningState": "",
+				"SpawningFaction": "$faction_none;",
+				"ThreatLevel": 4,
+				"TimeRemaining": 1557.5
+			}
+		]
+	}
+}`
+
+func TestParseFSSSignalDiscovered(t *testing.T) {
+	result, err := parseJSON(compress(t, fssSignalDiscoveredFixture))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	discovered, ok := result.Message.(FSSSignalDiscovered)
+
+	if !ok {
+		t.Fatalf("Expected FSSSignalDiscovered, got %T", result.Message)
+	}
+
+	signals := discovered.Message.Signals
+
+	if discovered.Message.SystemAddress != 2862335682961 || len(signals) != 2 {
+		t.Fatalf("Unexpected message: %+v", discovered.Message)
+	}
+
+	carrier, uss := signals[0], signals[1]
+
+	if !carrier.IsStation || carrier.SignalType != "FleetCarrier" ||
+		carrier.TimeRemaining != nil {
+		t.Errorf("Unexpected carrier signal: %+v", carrier)
+	}
+
+	if uss.IsStation || uss.USSType != "$USS_Type_NonHuman;" ||
+		uss.ThreatLevel != 4 || uss.TimeRemaining == nil ||
+		*uss.TimeRemaining != 1557.5 {
+		t.Errorf("Unexpected USS signal: %+v", uss)
+	}
+}
+
+const codexEntryFixture = `{
+	"$schemaRef": "https://eddn.edcd.io/schemas/codexentry/1",
+	"header": {
+		"uploaderID": "abcdef0123456789",
+		"softwareName": "E:D Market Connector [Windows]",
+		"softwareVersion": "5.1.1"
+	},
+	"message": {
+		"timestamp": "2021-05-25T18:50:44Z",
+		"event": "CodexEntry",
+		"EntryID": 2420507,
+		"Name": "$Codex_Ent_Stratum_07_F_Name;",
+		"SubCategory": "$Codex_SubCategory_Organic_Structures;",
+		"Category": "$Codex_Category_Biology;",
+		"Region": "$Codex_RegionName_18;",
+		"System": "Pleione",
+		"SystemAddress": 2862335682961,
+		"StarPos": [-77.0, -146.78125, -344.125],
+		"BodyID": 12,
+		"BodyName": "Pleione 3 a",
+		"Latitude": -12.25,
+		"Longitude": 63.75
+	}
+}`
+
+func TestParseCodexEntry(t *testing.T) {
+	result, err := parseJSON(compress(t, codexEntryFixture))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	entry, ok := result.Message.(CodexEntry)
+
+	if !ok {
+		t.Fatalf("Expected CodexEntry, got %T", result.Message)
+	}
+
+	msg := entry.Message
+
+	if msg.EntryID != 2420507 || msg.Name != "$Codex_Ent_Stratum_07_F_Name;" ||
+		msg.Category != "$Codex_Category_Biology;" ||
+		msg.Region != "$Codex_RegionName_18;" || msg.System != "Pleione" ||
+		msg.BodyName != "Pleione 3 a" || msg.Latitude == nil {
+		t.Errorf("Unexpected codex entry: %+v", msg)
+	}
+
+	if msg.NameLocalised != "" {
+		t.Errorf("Expected no localised name, got %q", msg.NameLocalised)
+	}
+}
+
+const fssBodySignalsFixture = `{
+	"$schemaRef": "https://eddn.edcd.io/schemas/fssbodysignals/1",
+	"header": {
+		"uploaderID": "abcdef0123456789",
+		"softwareName": "E:D Market Connector [Windows]",
+		"softwareVersion": "5.1.1"
+	},
+	"message": {
+		"timestamp": "2021-05-25T18:52:36Z",
+		"event": "FSSBodySignals",
+		"BodyID": 14,
+		"BodyName": "Pleione 3 a",
+		"SystemAddress": 2862335682961,
+		"StarSystem": "Pleione",
+		"StarPos": [-77.0, -146.78125, -344.125],
+		"Signals": [
+			{"Type": "$SAA_SignalType_Biological;", "Count": 3},
+			{"Type": "$SAA_SignalType_Geological;", "Count": 5}
+		]
+	}
+}`
+
+func TestParseFSSBodySignals(t *testing.T) {
+	result, err := parseJSON(compress(t, fssBodySignalsFixture))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	signals, ok := result.Message.(FSSBodySignals)
+
+	if !ok {
+		t.Fatalf("Expected FSSBodySignals, got %T", result.Message)
+	}
+
+	msg := signals.Message
+
+	if msg.BodyName != "Pleione 3 a" || msg.BodyID != 14 ||
+		msg.StarPos.X != -77.0 || len(msg.Signals) != 2 {
+		t.Fatalf("Unexpected message: %+v", msg)
+	}
+
+	if msg.Signals[0].Type != "$SAA_SignalType_Biological;" ||
+		msg.Signals[0].Count != 3 || msg.Signals[1].Count != 5 {
+		t.Errorf("Unexpected signals: %+v", msg.Signals)
+	}
+}
+
+const scanBaryCentreFixture = `{
+	"$schemaRef": "https://eddn.edcd.io/schemas/scanbarycentre/1",
+	"header": {
+		"uploaderID": "abcdef0123456789",
+		"softwareName": "E:D Market Connector [Windows]",
+		"softwareVersion": "5.1.1"
+	},
+	"message": {
+		"timestamp": "2021-05-25T18:55:02Z",
+		"event": "ScanBaryCentre",
+		"StarSystem": "Pleione",
+		"StarPos": [-77.0, -146.78125, -344.125],
+		"SystemAddress": 2862335682961,
+		"BodyID": 3,
+		"SemiMajorAxis": 1362530291080.4749,
+		"Eccentricity": 0.048616,
+		"OrbitalInclination": 4.126377,
+		"Periapsis": 38.690803,
+		"OrbitalPeriod": 1514933705.3337097,
+		"AscendingNode": -149.656685,
+		"MeanAnomaly": 291.431562
+	}
+}`
+
+func TestParseScanBaryCentre(t *testing.T) {
+	result, err := parseJSON(compress(t, scanBaryCentreFixture))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	baryCentre, ok := result.Message.(ScanBaryCentre)
+
+	if !ok {
+		t.Fatalf("Expected ScanBaryCentre, got %T", result.Message)
+	}
+
+	msg := baryCentre.Message
+
+	if msg.StarSystem != "Pleione" || msg.BodyID != 3 ||
+		msg.SystemAddress != 2862335682961 || msg.StarPos.Z != -344.125 {
+		t.Fatalf("Unexpected message: %+v", msg)
+	}
+
+	if msg.SemiMajorAxis != 1362530291080.4749 || msg.Eccentricity != 0.048616 ||
+		msg.OrbitalInclination != 4.126377 || msg.Periapsis != 38.690803 ||
+		msg.OrbitalPeriod != 1514933705.3337097 ||
+		msg.AscendingNode != -149.656685 || msg.MeanAnomaly != 291.431562 {
+		t.Errorf("Unexpected orbital elements: %+v", msg)
+	}
+}
+
+const fcMaterialsJournalFixture = `{
+	"$schemaRef": "https://eddn.edcd.io/schemas/fcmaterials_journal/1",
+	"header": {
+		"uploaderID": "abcdef0123456789",
+		"softwareName": "E:D Market Connector [Windows]",
+		"softwareVersion": "5.5.0"
+	},
+	"message": {
+		"timestamp": "2022-09-22T12:41:06Z",
+		"event": "FCMaterials",
+		"MarketID": 3707936512,
+		"CarrierName": "ODYSSEY BAR",
+		"CarrierID": "K7Z-N2B",
+		"Items": [
+			{"id": 128961524, "Name": "aerogel", "Name_Localised": "Aerogel",
+				"Price": 500, "Stock": 12, "Demand": 0},
+			{"id": 128962582, "Name": "biochemicalagent",
+				"Name_Localised": "Biochemical Agent", "Price": 9000, "Stock": 0,
+				"Demand": 30}
+		]
+	}
+}`
+
+const fcMaterialsCAPIFixture = `{
+	"$schemaRef": "https://eddn.edcd.io/schemas/fcmaterials_capi/1",
+	"header": {
+		"uploaderID": "abcdef0123456789",
+		"softwareName": "E:D Market Connector [Windows]",
+		"softwareVersion": "5.5.0"
+	},
+	"message": {
+		"timestamp": "2022-09-22T12:45:10Z",
+		"event": "FCMaterials",
+		"MarketID": 3707936512,
+		"CarrierID": "K7Z-N2B",
+		"Items": [
+			{"id": 128961524, "Name": "aerogel", "Price": 500, "Stock": 10,
+				"Demand": 0}
+		]
+	}
+}`
+
+func TestParseFCMaterials(t *testing.T) {
+	tests := []struct {
+		fixture string
+		source  FCMaterialsSource
+		items   int
+	}{
+		{fcMaterialsJournalFixture, FCMaterialsJournal, 2},
+		{fcMaterialsCAPIFixture, FCMaterialsCAPI, 1},
+	}
+
+	for _, test := range tests {
+		result, err := parseJSON(compress(t, test.fixture))
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		materials, ok := result.Message.(FCMaterials)
+
+		if !ok {
+			t.Fatalf("Expected FCMaterials, got %T", result.Message)
+		}
+
+		msg := materials.Message
+
+		if materials.Source != test.source || msg.MarketID != 3707936512 ||
+			msg.CarrierID != "K7Z-N2B" || len(msg.Items) != test.items {
+			t.Fatalf("Unexpected %s message: %+v", result.SchemaRef, materials)
+		}
+
+		if item := msg.Items[0]; item.Name != "aerogel" || item.Price != 500 ||
+			item.ID != 128961524 {
+			t.Errorf("Unexpected item: %+v", item)
+		}
+	}
+}
+
+func TestParseNewSchemaHost(t *testing.T) {
+	fixture := strings.Replace(commodity2Fixture,
+		"http://schemas.elite-markets.net/eddn/commodity/2",
+		"https://eddn.edcd.io/schemas/commodity/2", 1)
+
+	result, err := parseJSON(compress(t, fixture))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, ok := result.Message.(Commodity); !ok {
+		t.Fatalf("Expected Commodity, got %T", result.Message)
+	}
+}
+
+func TestParseUnsupportedVersion(t *testing.T) {
+	fixture := strings.Replace(commodity2Fixture, "commodity/2", "commodity/4", 1)
+
+	_, err := parseJSON(compress(t, fixture))
+
+	var unsupported *UnsupportedSchemaError
+
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("Expected UnsupportedSchemaError, got %v", err)
+	}
+
+	if unsupported.Ref != "http://schemas.elite-markets.net/eddn/commodity/4" {
+		t.Errorf("Unexpected schema ref in error: %q", unsupported.Ref)
+	}
+}
+
+func TestParseDeliverUnknown(t *testing.T) {
+	fixture := strings.Replace(commodity2Fixture, "commodity/2", "commodity/4", 1)
+
+	result, err := ParseMessageWithOptions([]byte(compress(t, fixture)),
+		ParseOptions{DeliverUnknown: true})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	unknown, ok := result.Message.(RawUnknown)
+
+	if !ok {
+		t.Fatalf("Expected RawUnknown, got %T", result.Message)
+	}
+
+	if unknown.SchemaRef != "http://schemas.elite-markets.net/eddn/commodity/4" ||
+		unknown.Header.SoftwareName != result.Header.SoftwareName {
+		t.Errorf("Unexpected unknown message: %+v", unknown)
+	}
+
+	var msg CommodityMessage
+
+	if err := json.Unmarshal(unknown.Message, &msg); err != nil {
+		t.Fatalf("Unexpected error decoding raw message: %v", err)
+	}
+
+	if msg.StationName != "Azeban Orbital" {
+		t.Errorf("Unexpected raw message: %s", unknown.Message)
+	}
+
+	// Test schemas are still disregarded.
+	fixture = strings.Replace(fixture, "commodity/4", "commodity/4/test", 1)
+
+	_, err = ParseMessageWithOptions([]byte(fixture), ParseOptions{DeliverUnknown: true})
+
+	var unsupported *UnsupportedSchemaError
+
+	if !errors.As(err, &unsupported) {
+		t.Errorf("Expected UnsupportedSchemaError, got %v", err)
+	}
+}
+
+func TestParseTestSchema(t *testing.T) {
+	fixture := strings.Replace(commodity2Fixture, "commodity/2", "commodity/2/test", 1)
+
+	_, err := parseJSON(compress(t, fixture))
+
+	var unsupported *UnsupportedSchemaError
+
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("Expected UnsupportedSchemaError, got %v", err)
+	}
+
+	if !strings.HasSuffix(unsupported.Ref, "/commodity/2/test") {
+		t.Errorf("Unexpected schema ref in error: %q", unsupported.Ref)
+	}
+
+	result, err := ParseMessageWithOptions([]byte(fixture),
+		ParseOptions{IncludeTest: true})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !result.IsTest {
+		t.Errorf("Expected IsTest to be set")
+	}
+
+	if commodity, ok := result.Message.(Commodity); !ok ||
+		commodity.Message.StationName != "Azeban Orbital" {
+		t.Errorf("Expected Commodity, got %+v", result.Message)
+	}
+
+	result, err = ParseMessageWithOptions([]byte(commodity2Fixture),
+		ParseOptions{IncludeTest: true})
+
+	if err != nil || result.IsTest {
+		t.Errorf("Expected a non-test message, got %+v, %v", result, err)
+	}
+}
+
+func TestParseMalformedPayload(t *testing.T) {
+	schemas := []string{
+		"commodity/1",
+		"commodity/2",
+		"commodity/3",
+		"journal/1",
+		"outfitting/1",
+		"outfitting/2",
+		"blackmarket/1",
+		"shipyard/1",
+		"shipyard/2",
+		"approachsettlement/1",
+		"navroute/1",
+		"fsssignaldiscovered/1",
+		"codexentry/1",
+		"fssbodysignals/1",
+		"scanbarycentre/1",
+		"fcmaterials_journal/1",
+		"fcmaterials_capi/1",
+	}
+
+	for _, schema := range schemas {
+		ref := "https://eddn.edcd.io/schemas/" + schema
+		fixture := `{
+			"$schemaRef": "` + ref + `",
+			"header": {
+				"uploaderID": "abcdef0123456789",
+				"softwareName": "Broken Uploader",
+				"softwareVersion": "v0.1"
+			},
+			"message": {"systemName": 5, "timestamp": [], "event": 5}
+		}`
+
+		result, err := parseJSON(compress(t, fixture))
+
+		if err == nil {
+			t.Errorf("%s: expected error, got %+v", schema, result)
+			continue
+		}
+
+		var unsupported *UnsupportedSchemaError
+
+		if errors.As(err, &unsupported) {
+			t.Errorf("%s: expected malformed payload error, got %v", schema, err)
+		}
+
+		if !strings.Contains(err.Error(), ref) {
+			t.Errorf("%s: expected schema ref in error, got %q", schema, err)
+		}
+
+		var decodeErr *DecodeError
+
+		if !errors.As(err, &decodeErr) || decodeErr.SchemaRef != ref {
+			t.Errorf("%s: expected DecodeError for %s, got %v", schema, ref, err)
+		}
+	}
+}
+
+func TestParseNotCompressed(t *testing.T) {
+	result, err := parseJSON("this is not zlib data")
+
+	if err == nil {
+		t.Fatalf("Expected error, got %+v", result)
+	}
+
+	if result.Message != nil {
+		t.Errorf("Expected nil message, got %+v", result.Message)
+	}
+}
+
+func TestParseCorruptZlib(t *testing.T) {
+	// A valid zlib header followed by garbage.
+	result, err := parseJSON("\x78\x9cthis is not deflate data")
+
+	if err == nil {
+		t.Fatalf("Expected error, got %+v", result)
+	}
+
+	if result.Message != nil {
+		t.Errorf("Expected nil message, got %+v", result.Message)
+	}
+}
+
+func TestParseReusesZlibReaders(t *testing.T) {
+	data := compress(t, commodity2Fixture)
+
+	// Readers returned to the pool after an error must still be usable.
+	for i := 0; i < 3; i++ {
+		if _, err := parseJSON("\x78\x9cthis is not deflate data"); err == nil {
+			t.Fatalf("Expected error parsing corrupt zlib data")
+		}
+
+		if _, err := parseJSON(data[:len(data)/2]); err == nil {
+			t.Fatalf("Expected error parsing truncated zlib data")
+		}
+
+		if _, err := parseJSON(data); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+}
+
+func TestParseMessageTooLarge(t *testing.T) {
+	data := compress(t, commodity2Fixture)
+	p := &parser{options: ParseOptions{
+		MaxMessageSize: int64(len(commodity2Fixture)) - 1}}
+
+	_, err := p.parseJSON(data)
+
+	var tooLarge *MessageTooLargeError
+
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("Expected MessageTooLargeError, got %v", err)
+	}
+
+	if tooLarge.Limit != p.options.MaxMessageSize {
+		t.Errorf("Expected limit %d, got %d", p.options.MaxMessageSize, tooLarge.Limit)
+	}
+
+	// Exactly the limit is fine.
+	p.options.MaxMessageSize++
+
+	if _, err := p.parseJSON(data); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// A small payload that decompresses to more than the default limit.
+	bomb := compress(t, strings.Repeat(" ", DefaultMaxMessageSize+1))
+
+	if _, err := parseJSON(bomb); !errors.As(err, &tooLarge) {
+		t.Errorf("Expected MessageTooLargeError, got %v", err)
+	}
+}
+
+func TestParseMalformedNoLeak(t *testing.T) {
+	data := compress(t, commodity2Fixture)
+	badChecksum := data[:len(data)-1] + string(data[len(data)-1]^0xff)
+	bomb := compress(t, strings.Repeat(" ", 1<<16))
+	p := &parser{options: ParseOptions{MaxMessageSize: 1 << 15}}
+
+	malformed := []string{
+		"\x78\x9cthis is not deflate data", // Valid header, invalid stream
+		"\x78\x9c",                         // Header only
+		"\x78\xbb" + data[2:],              // Header asking for a dictionary
+		data[:len(data)/2],                 // Truncated
+		badChecksum,                        // Invalid adler32 checksum
+		bomb,                               // Too large once decompressed
+	}
+
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 1000; i++ {
+		for _, frame := range malformed {
+			if _, err := p.parseJSON(frame); err == nil {
+				t.Fatalf("Expected error parsing %q", frame)
+			}
+		}
+	}
+
+	// The pooled readers must still work after all of that.
+	if _, err := p.parseJSON(data); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("Goroutines leaked: %d before, %d after", before, after)
+	}
+
+	putZlibReader(nil)
+}
+
+func TestParseUncompressed(t *testing.T) {
+	result, err := parseJSON(commodity2Fixture)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, ok := result.Message.(Commodity); !ok {
+		t.Fatalf("Expected Commodity, got %T", result.Message)
+	}
+
+	result, err = parseJSONRaw([]byte(commodity2Fixture))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, ok := result.Message.(Commodity); !ok {
+		t.Fatalf("Expected Commodity, got %T", result.Message)
+	}
+}
+
+func TestParserAccept(t *testing.T) {
+	var seen string
+
+	p := &parser{accept: func(root Root) bool {
+		seen = root.SchemaRef
+		return false
+	}}
+
+	// The payload is never decoded so this is filtered rather than failing.
+	fixture := strings.Replace(commodity2Fixture, `"stationName"`, `"stationName": 5, "x"`, 1)
+	result, err := p.parseJSON(compress(t, fixture))
+
+	if !errors.Is(err, errFiltered) {
+		t.Fatalf("Expected filtered error, got %v", err)
+	}
+
+	if result.Message != nil {
+		t.Errorf("Expected nil message, got %+v", result.Message)
+	}
+
+	if seen != "http://schemas.elite-markets.net/eddn/commodity/2" {
+		t.Errorf("Unexpected schema ref given to accept: %q", seen)
+	}
+}
+
+func BenchmarkParseJSONRaw(b *testing.B) {
+	fixtures := map[string][]byte{
+		"commodity": []byte(commodity2Fixture),
+		"journal": []byte(journalFixture(`{
+			"timestamp": "2017-01-01T12:00:00Z",
+			"event": "FSDJump",
+			"StarSystem": "Pleione",
+			"SystemAddress": 2862335682961,
+			"StarPos": [-77.0, -146.78125, -344.125]
+		}`)),
+	}
+
+	for name, fixture := range fixtures {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := parseJSONRaw(fixture); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParseJSON(b *testing.B) {
+	data := compress(b, commodity2Fixture)
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := parseJSON(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParseIncludeRaw(t *testing.T) {
+	result, err := ParseMessageWithOptions([]byte(compress(t, commodity2Fixture)),
+		ParseOptions{IncludeRaw: true})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if string(result.Raw) != commodity2Fixture {
+		t.Errorf("Unexpected raw message: %s", result.Raw)
+	}
+
+	result, err = ParseMessage([]byte(compress(t, commodity2Fixture)))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Raw != nil {
+		t.Errorf("Raw set without IncludeRaw: %s", result.Raw)
+	}
+}
+
+func TestParseStrict(t *testing.T) {
+	strict := ParseOptions{Strict: true}
+
+	if _, err := ParseMessageWithOptions([]byte(commodity2Fixture), strict); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// An unknown field in the message, or in the envelope.
+	unknown := strings.Replace(commodity2Fixture, `"demandLevel": "Med"`,
+		`"demandLevel": "Med", "meanPrice": 300`, 1)
+	envelope := strings.Replace(commodity2Fixture, `"header": {`,
+		`"relay": "eddn", "header": {`, 1)
+
+	for _, fixture := range []string{unknown, envelope} {
+		if _, err := ParseMessage([]byte(fixture)); err != nil {
+			t.Errorf("Unexpected error without Strict: %v", err)
+		}
+
+		if _, err := ParseMessageWithOptions([]byte(fixture), strict); err == nil {
+			t.Errorf("Expected an error for an unknown field")
+		}
+	}
+
+	var decodeErr *DecodeError
+
+	if _, err := ParseMessageWithOptions([]byte(unknown), strict); !errors.As(err, &decodeErr) {
+		t.Errorf("Expected DecodeError, got %v", err)
+	}
+}
+
+func TestParseErrorCategories(t *testing.T) {
+	unsupported := strings.Replace(commodity2Fixture, "commodity/2", "commodity/4", 1)
+	event := journalFixture(`{"timestamp": "2021-05-25T18:06:08Z", "event": "Music"}`)
+
+	tests := []struct {
+		data     string
+		category error
+	}{
+		{"\x78\x9cthis is not deflate data", ErrDecompress},
+		{"\x1f\x8bthis is not gzip data", ErrDecompress},
+		{"not a message", ErrJSON},
+		{compress(t, "{\"$schemaRef\": "), ErrJSON},
+		{unsupported, ErrUnsupportedSchema},
+		{event, ErrUnsupportedEvent},
+	}
+
+	categories := []error{ErrDecompress, ErrJSON, ErrUnsupportedSchema,
+		ErrUnsupportedEvent}
+
+	for _, test := range tests {
+		_, err := ParseMessage([]byte(test.data))
+
+		for _, category := range categories {
+			if is := errors.Is(err, category); is != (category == test.category) {
+				t.Errorf("errors.Is(%v, %v) = %v", err, category, is)
+			}
+		}
+	}
+
+	// The typed errors may still be found.
+	var unsupportedErr *UnsupportedSchemaError
+
+	if _, err := ParseMessage([]byte(unsupported)); !errors.As(err, &unsupportedErr) {
+		t.Errorf("Expected UnsupportedSchemaError, got %v", err)
+	}
+}
+
+func TestParseZlibTrailingBytes(t *testing.T) {
+	for _, trailer := range []string{"\x00\x00\x00\x00", "\n", "garbage"} {
+		result, err := parseJSON(compress(t, commodity2Fixture) + trailer)
+
+		if err != nil {
+			t.Errorf("Unexpected error with trailer %q: %v", trailer, err)
+			continue
+		}
+
+		if _, ok := result.Message.(Commodity); !ok {
+			t.Errorf("Expected Commodity, got %T", result.Message)
+		}
+	}
+}
+
+func TestParseBase64(t *testing.T) {
+	frame := base64.StdEncoding.EncodeToString([]byte(compress(t, commodity2Fixture)))
+
+	result, err := ParseBase64(frame + "\n")
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, ok := result.Message.(Commodity); !ok {
+		t.Errorf("Expected Commodity, got %T", result.Message)
+	}
+
+	_, err = ParseBase64("not base64!")
+
+	var corrupt base64.CorruptInputError
+
+	if !errors.As(err, &corrupt) {
+		t.Errorf("Expected CorruptInputError, got %v", err)
+	}
+}
+
+func TestParseGzip(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(commodity2Fixture))
+	w.Close()
+
+	result, err := ParseMessage(buf.Bytes())
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, ok := result.Message.(Commodity); !ok {
+		t.Errorf("Expected Commodity, got %T", result.Message)
+	}
+
+	// The size limit applies to gzip messages too.
+	_, err = ParseMessageWithOptions(buf.Bytes(), ParseOptions{MaxMessageSize: 16})
+
+	var tooLarge *MessageTooLargeError
+
+	if !errors.As(err, &tooLarge) {
+		t.Errorf("Expected MessageTooLargeError, got %v", err)
+	}
+
+	// A truncated gzip message is an error, not plain JSON.
+	if _, err := ParseMessage(buf.Bytes()[:10]); err == nil {
+		t.Errorf("Expected error for truncated gzip message")
+	}
+}
+
+// marketFixture returns a commodity/3 message for a market selling n
+// commodities, which is typical of the messages that make up most of the
+// traffic on EDDN.
+func marketFixture(n int) string {
+	var commodities []string
+
+	for i := 0; i < n; i++ {
+		commodities = append(commodities, fmt.Sprintf(`{"name": "commodity%d",
+			"meanPrice": %d, "buyPrice": %d, "stock": %d, "stockBracket": 2,
+			"sellPrice": %d, "demand": 1, "demandBracket": 0,
+			"statusFlags": ["Producer"]}`, i, 1000+i, 900+i, 500*i, 850+i))
+	}
+
+	return `{
+		"$schemaRef": "https://eddn.edcd.io/schemas/commodity/3",
+		"header": {"uploaderID": "abcdef0123456789", "softwareName": "EDMC",
+			"softwareVersion": "5.1.1",
+			"gatewayTimestamp": "2021-05-25T18:40:12.123456Z"},
+		"message": {
+			"systemName": "Eranin",
+			"stationName": "Azeban City",
+			"stationType": "Coriolis",
+			"timestamp": "2021-05-25T18:40:11Z",
+			"economies": [{"name": "Industrial", "proportion": 1}],
+			"commodities": [` + strings.Join(commodities, ",") + `]
+		}
+	}`
+}
+
+func TestParseCommodityPreallocation(t *testing.T) {
+	// Braces inside strings must not inflate the allocation.
+	fixture := strings.Replace(marketFixture(2), `"Azeban City"`,
+		`"`+strings.Repeat("{", 100000)+`"`, 1)
+
+	result, err := parseJSON(compress(t, fixture))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	commodities := result.Message.(Commodity).Message.Commodities
+
+	if len(commodities) != 2 || cap(commodities) > maxPreallocatedCommodities {
+		t.Errorf("Unexpected commodities: len %d, cap %d", len(commodities),
+			cap(commodities))
+	}
+}
+
+// benchmarkParse parses the zlib compressed fixture as it would be received
+// from the relay.
+func benchmarkParse(b *testing.B, fixture string) {
+	data := compress(b, fixture)
+
+	if _, err := parseJSON(data); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(fixture)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := parseJSON(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseCommodity(b *testing.B) {
+	benchmarkParse(b, marketFixture(120))
+}
+
+func BenchmarkParseCommodity1(b *testing.B) {
+	benchmarkParse(b, commodity1Fixture)
+}
+
+func BenchmarkParseCommodity2(b *testing.B) {
+	benchmarkParse(b, commodity2Fixture)
+}
+
+func BenchmarkParseJournal(b *testing.B) {
+	benchmarkParse(b, journalFixture(`{
+		"timestamp": "2017-01-01T12:00:00Z",
+		"event": "FSDJump",
+		"StarSystem": "Pleione",
+		"SystemAddress": 2862335682961,
+		"StarPos": [-77.0, -146.78125, -344.125]
+	}`))
+}
+
+const outfitting2Fixture = `{
+	"$schemaRef": "https://eddn.edcd.io/schemas/outfitting/2",
+	"header": {
+		"uploaderID": "abcdef0123456789",
+		"softwareName": "My Awesome Outfitting Uploader",
+		"softwareVersion": "v3.14"
+	},
+	"message": {
+		"systemName": "Eranin",
+		"stationName": "Azeban Orbital",
+		"timestamp": "2017-01-01T12:00:00Z",
+		"modules": [
+			"Hpt_PulseLaser_Gimbal_Small",
+			"Int_Hyperdrive_Size5_Class5",
+			"Int_ShieldGenerator_Size4_Class3",
+			"Int_FuelScoop_Size3_Class5"
+		]
+	}
+}`
+
+func BenchmarkParseOutfitting(b *testing.B) {
+	benchmarkParse(b, outfitting2Fixture)
+}
+
+const shipyard2Fixture = `{
+	"$schemaRef": "https://eddn.edcd.io/schemas/shipyard/2",
+	"header": {
+		"uploaderID": "abcdef0123456789",
+		"softwareName": "My Awesome Shipyard Uploader",
+		"softwareVersion": "v3.14"
+	},
+	"message": {
+		"systemName": "Eranin",
+		"stationName": "Azeban Orbital",
+		"timestamp": "2017-01-01T12:00:00Z",
+		"ships": ["SideWinder", "Eagle", "CobraMkIII", "Viper"]
+	}
+}`
+
+func BenchmarkParseShipyard(b *testing.B) {
+	benchmarkParse(b, shipyard2Fixture)
+}
+
+func BenchmarkParseBlackmarket(b *testing.B) {
+	benchmarkParse(b, blackmarketFixture)
+}
+
+func BenchmarkParseApproachSettlement(b *testing.B) {
+	benchmarkParse(b, approachSettlementFixture)
+}
+
+func BenchmarkParseNavRoute(b *testing.B) {
+	benchmarkParse(b, navRouteFixture)
+}
+
+func BenchmarkParseFSSSignalDiscovered(b *testing.B) {
+	benchmarkParse(b, fssSignalDiscoveredFixture)
+}
+
+func BenchmarkParseCodexEntry(b *testing.B) {
+	benchmarkParse(b, codexEntryFixture)
+}
+
+func BenchmarkParseFSSBodySignals(b *testing.B) {
+	benchmarkParse(b, fssBodySignalsFixture)
+}
+
+func BenchmarkParseScanBaryCentre(b *testing.B) {
+	benchmarkParse(b, scanBaryCentreFixture)
+}
+
+func TestParseTiming(t *testing.T) {
+	data := []byte(compress(t, commodity2Fixture))
+
+	result, err := ParseMessageWithOptions(data, ParseOptions{Timing: true})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Duration <= 0 || result.DecodeDuration <= 0 ||
+		result.DecodeDuration > result.Duration {
+		t.Errorf("Unexpected durations: %v, and %v", result.Duration,
+			result.DecodeDuration)
+	}
+
+	result, err = ParseMessage(data)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Duration != 0 || result.DecodeDuration != 0 {
+		t.Errorf("Durations recorded without Timing: %v, and %v",
+			result.Duration, result.DecodeDuration)
+	}
+}
+
+func TestParseHeaderExpansionFlags(t *testing.T) {
+	fixture := strings.Replace(commodity2Fixture, `"softwareVersion": "v3.14"`,
+		`"softwareVersion": "v3.14", "horizons": true, "odyssey": false`, 1)
+
+	result, err := parseJSON(compress(t, fixture))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	header := result.Header
+
+	if header.Horizons == nil || !*header.Horizons ||
+		header.Odyssey == nil || *header.Odyssey {
+		t.Errorf("Unexpected flags: horizons %v, odyssey %v", header.Horizons,
+			header.Odyssey)
+	}
+
+	result, err = parseJSON(compress(t, commodity2Fixture))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Header.Horizons != nil || result.Header.Odyssey != nil {
+		t.Errorf("Expected no flags for a legacy message, got %+v", result.Header)
+	}
+}
+
+func TestParseBatchMessage(t *testing.T) {
+	fixture := `{
+		"$schemaRef": "https://eddn.edcd.io/schemas/commodity/3",
+		"header": {"uploaderID": "abcdef0123456789", "softwareName": "Batcher",
+			"softwareVersion": "1.0"},
+		"message": [
+			{"systemName": "Eranin", "stationName": "Azeban City",
+				"timestamp": "2021-05-25T18:40:11Z", "commodities": []},
+			{"systemName": "Sol", "stationName": "Abraham Lincoln",
+				"timestamp": "2021-05-25T18:40:12Z", "commodities": []}
+		]
+	}`
+
+	for _, options := range []ParseOptions{{}, {Validate: true}} {
+		result, err := ParseMessageWithOptions([]byte(compress(t, fixture)), options)
+
+		var batch *BatchMessageError
+
+		if !errors.As(err, &batch) {
+			t.Fatalf("Expected BatchMessageError with %+v, got %v", options, err)
+		}
+
+		if batch.SchemaRef != "https://eddn.edcd.io/schemas/commodity/3" {
+			t.Errorf("Unexpected schema: %s", batch.SchemaRef)
+		}
+
+		if result.Message != nil {
+			t.Errorf("Expected no message, got %+v", result.Message)
+		}
+	}
+
+	// Unsupported schemas are still delivered as they are with DeliverUnknown.
+	unknown := strings.Replace(fixture, "commodity/3", "batchtest/1", 1)
+	result, err := ParseMessageWithOptions([]byte(unknown),
+		ParseOptions{DeliverUnknown: true})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, ok := result.Message.(RawUnknown); !ok {
+		t.Errorf("Expected RawUnknown, got %T", result.Message)
+	}
+}
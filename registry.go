@@ -0,0 +1,290 @@
+package EDDNClient
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// JournalEventDecoder decodes a single journal event into its native Go type.
+// event contains the journal message exactly as it was received, decoded as
+// encoding/json would, except that integers too large to be held exactly by a
+// float64, such as the SystemAddress, are an int64 rather than a float64.
+type JournalEventDecoder func(event map[string]interface{}) (interface{}, error)
+
+// SchemaDecoder decodes a single decompressed EDDN message into its native Go
+// type.  raw contains the entire message including the $schemaRef, and
+// header.
+type SchemaDecoder func(raw []byte) (interface{}, error)
+
+// journalDecoder decodes a single journal event using the options of the
+// parser.  The built-in events decode with them, and a JournalEventDecoder
+// registered by the receiver ignores them.
+type journalDecoder func(event map[string]interface{},
+	options JournalDecodeOptions) (interface{}, error)
+
+// rootDecoder decodes a message whose Root has already been parsed.  The
+// built-in schemas decode root.Message directly so the envelope is only
+// parsed once.  raw is kept for any SchemaDecoder registered by the receiver.
+type rootDecoder func(root Root, raw []byte) (interface{}, error)
+
+// The categories of error returned while parsing a message, which may be
+// matched with errors.Is.  The typed errors, such as UnsupportedSchemaError,
+// match the category they belong to, and may still be found with errors.As.
+var (
+	ErrDecompress        = errors.New("decompressing message")
+	ErrJSON              = errors.New("invalid JSON")
+	ErrUnsupportedSchema = errors.New("unsupported schema")
+	ErrUnsupportedEvent  = errors.New("unsupported journal event")
+)
+
+// categoryError places Err in one of the categories of error, while keeping
+// it in the chain for errors.Is, and errors.As.
+type categoryError struct {
+	category error
+	err      error
+}
+
+func (e *categoryError) Error() string {
+	return fmt.Sprintf("%v: %v", e.category, e.err)
+}
+
+func (e *categoryError) Is(target error) bool {
+	return target == e.category
+}
+
+func (e *categoryError) Unwrap() error {
+	return e.err
+}
+
+// UnhandledEventError is returned when a journal message contains an event
+// that has no JournalEventDecoder registered.
+type UnhandledEventError struct {
+	Event string // Name of the unhandled event
+}
+
+func (e *UnhandledEventError) Error() string {
+	return fmt.Sprintf("unhandled journal event %q", e.Event)
+}
+
+func (e *UnhandledEventError) Is(target error) bool {
+	return target == ErrUnsupportedEvent
+}
+
+// JournalDecodeError is returned when a journal event can't be decoded into
+// its Go type.  i.e. when a field has an unexpected type.
+type JournalDecodeError struct {
+	Event string // Name of the event
+	Err   error  // The error returned by the JournalEventDecoder
+}
+
+func (e *JournalDecodeError) Error() string {
+	return fmt.Sprintf("decoding journal event %q: %v", e.Event, e.Err)
+}
+
+func (e *JournalDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidEventError is returned when a journal message has no event field, or
+// the event isn't a string.
+type InvalidEventError struct {
+	Event interface{} // The event field, or nil if it's missing
+}
+
+func (e *InvalidEventError) Error() string {
+	if e.Event == nil {
+		return "journal message has no event"
+	}
+
+	return fmt.Sprintf("journal event %v is a %T, not a string", e.Event, e.Event)
+}
+
+// UnsupportedSchemaError is returned when a message uses a schema that has no
+// SchemaDecoder registered, or is a test schema.
+type UnsupportedSchemaError struct {
+	Ref string // The $schemaRef of the message
+}
+
+func (e *UnsupportedSchemaError) Error() string {
+	return fmt.Sprintf("schema not supported: %s", e.Ref)
+}
+
+func (e *UnsupportedSchemaError) Is(target error) bool {
+	return target == ErrUnsupportedSchema
+}
+
+// BatchMessageError is returned for a message that is a JSON array rather
+// than an object, which some non-standard relays send to batch several
+// messages together.  EDDN itself never does, and batches aren't decoded.
+type BatchMessageError struct {
+	SchemaRef string // The schema of the message
+}
+
+func (e *BatchMessageError) Error() string {
+	return fmt.Sprintf("message using %s is a batch, which isn't supported",
+		e.SchemaRef)
+}
+
+// DecodeError is returned when a message using a supported schema can't be
+// decoded into its Go type.
+type DecodeError struct {
+	SchemaRef string // The $schemaRef of the message
+	Err       error  // The error returned by the decoder
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%s: %v", e.SchemaRef, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	schemasMutex sync.RWMutex
+	schemas      = make(map[string]rootDecoder)
+
+	journalEventsMutex sync.RWMutex
+	journalEvents      = make(map[string]journalDecoder)
+)
+
+// RegisterSchema registers decode as the decoder for any message using the
+// schema ref.  This allows the receiver to handle schemas that aren't yet
+// supported by this package.  ref may be a full schema reference from either
+// EDDN host, or just the name and version.  i.e. "navroute/1".  Messages
+// using the "/test" variant of ref are still disregarded.  Registering a
+// schema that already has a decoder, including the built-in schemas,
+// replaces it.  Passing a nil decode removes the schema.
+//
+// RegisterSchema panics if ref doesn't contain a valid name and version.
+func RegisterSchema(ref string, decode SchemaDecoder) {
+	if decode == nil {
+		registerSchema(ref, nil)
+		return
+	}
+
+	registerSchema(ref, func(root Root, raw []byte) (interface{}, error) {
+		return decode(raw)
+	})
+}
+
+// registerSchema is the same as RegisterSchema, but registers a rootDecoder.
+func registerSchema(ref string, decode rootDecoder) {
+	name, version, err := schemaKey(ref)
+
+	if err != nil {
+		panic(err)
+	}
+
+	key := fmt.Sprintf("%s/%d", name, version)
+
+	schemasMutex.Lock()
+	defer schemasMutex.Unlock()
+
+	if decode == nil {
+		delete(schemas, key)
+		return
+	}
+
+	schemas[key] = decode
+}
+
+// schemaDecoder returns the decoder registered for the given schema name and
+// version, if any.
+func schemaDecoder(name string, version int) (decode rootDecoder, ok bool) {
+	schemasMutex.RLock()
+	defer schemasMutex.RUnlock()
+
+	decode, ok = schemas[fmt.Sprintf("%s/%d", name, version)]
+
+	return decode, ok
+}
+
+// SupportedSchemas returns the name and version of every schema that can be
+// parsed, including those registered with RegisterSchema, in sorted order.
+// i.e. "commodity/3".  The "/test" variants aren't listed, but are parsed
+// the same way when IncludeTest is set.
+func SupportedSchemas() []string {
+	schemasMutex.RLock()
+	defer schemasMutex.RUnlock()
+
+	supported := make([]string, 0, len(schemas))
+
+	for key := range schemas {
+		supported = append(supported, key)
+	}
+
+	sort.Strings(supported)
+
+	return supported
+}
+
+// RegisterJournalEvent registers decode as the decoder for any journal
+// message with the given event name.  This allows the receiver to handle
+// events that aren't yet supported by this package.  Registering an event
+// that already has a decoder, including the built-in events, replaces it.
+// Passing a nil decode removes the event.
+func RegisterJournalEvent(event string, decode JournalEventDecoder) {
+	if decode == nil {
+		registerJournalEvent(event, nil)
+		return
+	}
+
+	registerJournalEvent(event, func(event map[string]interface{},
+		options JournalDecodeOptions) (interface{}, error) {
+		return decode(event)
+	})
+}
+
+// registerJournalEvent registers decode as the decoder for event, removing
+// the event if decode is nil.  See RegisterJournalEvent.
+func registerJournalEvent(event string, decode journalDecoder) {
+	journalEventsMutex.Lock()
+	defer journalEventsMutex.Unlock()
+
+	if decode == nil {
+		delete(journalEvents, event)
+		return
+	}
+
+	journalEvents[event] = decode
+}
+
+// journalEventDecoder returns the decoder registered for event, if any.
+func journalEventDecoder(event string) (decode journalDecoder, ok bool) {
+	journalEventsMutex.RLock()
+	defer journalEventsMutex.RUnlock()
+
+	decode, ok = journalEvents[event]
+
+	return decode, ok
+}
+
+// JournalDecodeOptions contains the options used when decoding journal events
+// with mapstructure, given as the JournalDecode of ParseOptions, or a
+// ChannelInterfaceConfig.  The zero value is the default, and decodes each
+// field strictly.
+type JournalDecodeOptions struct {
+	// Convert between numbers, strings, and bools where the field's type
+	// doesn't match, as some uploaders send numbers as strings or vice
+	// versa.  See mapstructure.DecoderConfig.WeaklyTypedInput.
+	WeaklyTypedInput bool
+
+	// OnUnused, when set, is called after decoding an event that contained
+	// fields the Go type doesn't have, with the event name and those fields.
+	// This is useful for finding schema drift.  It may be called from any
+	// goroutine parsing messages.
+	OnUnused func(event string, unused []string)
+}
+
+// ScanFilteredError is returned for Scan events disregarded by the ScanFilter
+// of ParseOptions, or a ChannelInterfaceConfig.
+type ScanFilteredError struct {
+	BodyName string // BodyName of the scan, if it had one
+}
+
+func (e *ScanFilteredError) Error() string {
+	return fmt.Sprintf("scan of %q disregarded by the scan filter", e.BodyName)
+}
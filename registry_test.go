@@ -0,0 +1,170 @@
+package EDDNClient
+
+import (
+	"errors"
+	"sort"
+	"testing"
+)
+
+type journalUndockedTest struct {
+	StationName string
+}
+
+func TestRegisterJournalEvent(t *testing.T) {
+	msg := map[string]interface{}{
+		"event":       "UndockedTest",
+		"StationName": "Azeban Orbital",
+	}
+
+	_, err := handleJournalMessage(msg)
+
+	var unhandled *UnhandledEventError
+
+	if !errors.As(err, &unhandled) || unhandled.Event != "UndockedTest" {
+		t.Fatalf("Expected unhandled event error, got %v", err)
+	}
+
+	RegisterJournalEvent("UndockedTest",
+		func(event map[string]interface{}) (interface{}, error) {
+			name, _ := event["StationName"].(string)
+			return journalUndockedTest{name}, nil
+		})
+	defer RegisterJournalEvent("UndockedTest", nil)
+
+	out, err := handleJournalMessage(msg)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if undocked, ok := out.(journalUndockedTest); !ok ||
+		undocked.StationName != "Azeban Orbital" {
+		t.Errorf("Unexpected result: %+v", out)
+	}
+}
+
+func TestBuiltinJournalEventsRegistered(t *testing.T) {
+	for _, event := range []string{"FSDJump", "Docked", "Scan"} {
+		if _, ok := journalEventDecoder(event); !ok {
+			t.Errorf("Expected %s to be registered", event)
+		}
+	}
+}
+
+func TestRegisterSchema(t *testing.T) {
+	fixture := `{
+		"$schemaRef": "https://eddn.edcd.io/schemas/registrytest/1",
+		"header": {
+			"uploaderID": "abcdef0123456789",
+			"softwareName": "Test Uploader",
+			"softwareVersion": "v1.0"
+		},
+		"message": {"value": 42}
+	}`
+
+	var unsupported *UnsupportedSchemaError
+
+	if _, err := parseJSON(fixture); !errors.As(err, &unsupported) {
+		t.Fatalf("Expected UnsupportedSchemaError, got %v", err)
+	}
+
+	RegisterSchema("registrytest/1", func(raw []byte) (interface{}, error) {
+		return string(raw), nil
+	})
+	defer RegisterSchema("registrytest/1", nil)
+
+	result, err := parseJSON(fixture)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Message != fixture {
+		t.Errorf("Expected the raw message, got %v", result.Message)
+	}
+
+	RegisterSchema("registrytest/1", func(raw []byte) (interface{}, error) {
+		return nil, errors.New("decode failed")
+	})
+
+	if _, err := parseJSON(fixture); err == nil ||
+		errors.As(err, &unsupported) {
+		t.Errorf("Expected decode error, got %v", err)
+	}
+}
+
+func TestRegisterSchemaMalformed(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected panic")
+		}
+	}()
+
+	RegisterSchema("registrytest", func(raw []byte) (interface{}, error) {
+		return nil, nil
+	})
+}
+
+func TestBuiltinSchemasRegistered(t *testing.T) {
+	builtins := []struct {
+		name    string
+		version int
+	}{
+		{"commodity", 1}, {"commodity", 2}, {"commodity", 3},
+		{"journal", 1},
+		{"outfitting", 1}, {"outfitting", 2},
+		{"blackmarket", 1},
+		{"shipyard", 1}, {"shipyard", 2},
+		{"approachsettlement", 1},
+		{"navroute", 1},
+		{"fsssignaldiscovered", 1},
+		{"codexentry", 1},
+		{"fssbodysignals", 1},
+		{"scanbarycentre", 1},
+		{"fcmaterials_journal", 1}, {"fcmaterials_capi", 1},
+	}
+
+	for _, builtin := range builtins {
+		if _, ok := schemaDecoder(builtin.name, builtin.version); !ok {
+			t.Errorf("Expected %s/%d to be registered", builtin.name,
+				builtin.version)
+		}
+	}
+}
+
+func TestSupportedSchemas(t *testing.T) {
+	supported := SupportedSchemas()
+
+	if !sort.StringsAreSorted(supported) {
+		t.Errorf("Expected sorted schemas, got %v", supported)
+	}
+
+	for _, schema := range []string{"commodity/3", "journal/1", "navroute/1"} {
+		if !containsString(supported, schema) {
+			t.Errorf("Expected %s to be supported, got %v", schema, supported)
+		}
+	}
+
+	RegisterSchema("https://eddn.edcd.io/schemas/registrytest/2",
+		func(raw []byte) (interface{}, error) { return nil, nil })
+
+	if !containsString(SupportedSchemas(), "registrytest/2") {
+		t.Errorf("Expected a registered schema to be supported")
+	}
+
+	RegisterSchema("registrytest/2", nil)
+
+	if containsString(SupportedSchemas(), "registrytest/2") {
+		t.Errorf("Expected a removed schema not to be supported")
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
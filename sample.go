@@ -0,0 +1,103 @@
+package EDDNClient
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket limits a schema to rate messages per second, allowing a burst
+// of up to a second's worth.
+type tokenBucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// take reports whether a message may be delivered at now, using up a token
+// if so.
+func (b *tokenBucket) take(now time.Time) bool {
+	burst := b.rate
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+	}
+
+	b.last = now
+
+	if b.tokens > burst {
+		b.tokens = burst
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+
+	return true
+}
+
+// sampler applies the SampleEvery, and RateLimits of a
+// ChannelInterfaceConfig.  The schemas are fixed once it's created, so only
+// the counts, and buckets need the mutex.
+type sampler struct {
+	mutex   sync.Mutex
+	every   map[string]int
+	counts  map[string]int
+	buckets map[string]*tokenBucket
+}
+
+// newSampler returns the sampler for config, or nil if it has nothing to
+// sample.
+func newSampler(config ChannelInterfaceConfig) *sampler {
+	if len(config.SampleEvery) == 0 && len(config.RateLimits) == 0 {
+		return nil
+	}
+
+	s := &sampler{
+		every:   make(map[string]int, len(config.SampleEvery)),
+		counts:  make(map[string]int, len(config.SampleEvery)),
+		buckets: make(map[string]*tokenBucket, len(config.RateLimits))}
+
+	for name, n := range config.SampleEvery {
+		if n > 1 {
+			s.every[name] = n
+		}
+	}
+
+	for name, rate := range config.RateLimits {
+		if rate > 0 {
+			s.buckets[name] = &tokenBucket{rate: rate}
+		}
+	}
+
+	return s
+}
+
+// keep reports whether a message using the schema named name is kept at now.
+// Messages are sampled first so those dropped by it don't use up the rate.
+func (s *sampler) keep(name string, now time.Time) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if n, ok := s.every[name]; ok {
+		count := s.counts[name]
+		s.counts[name] = (count + 1) % n
+
+		if count != 0 {
+			return false
+		}
+	}
+
+	if bucket, ok := s.buckets[name]; ok {
+		return bucket.take(now)
+	}
+
+	return true
+}
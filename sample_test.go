@@ -0,0 +1,69 @@
+package EDDNClient
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSamplerEvery(t *testing.T) {
+	s := newSampler(ChannelInterfaceConfig{SampleEvery: map[string]int{"journal": 3}})
+	now := time.Now()
+	kept := 0
+
+	for i := 0; i < 9; i++ {
+		if s.keep("journal", now) {
+			kept++
+		}
+
+		if !s.keep("commodity", now) {
+			t.Errorf("Commodity sampled without a rate")
+		}
+	}
+
+	if kept != 3 {
+		t.Errorf("Expected 3 of 9 journals kept, got %d", kept)
+	}
+}
+
+func TestSamplerRateLimit(t *testing.T) {
+	s := newSampler(ChannelInterfaceConfig{RateLimits: map[string]float64{"journal": 2}})
+	now := time.Now()
+
+	// A second's worth may be kept at once.
+	if !s.keep("journal", now) || !s.keep("journal", now) || s.keep("journal", now) {
+		t.Errorf("Expected a burst of 2 journals")
+	}
+
+	if s.keep("journal", now.Add(100*time.Millisecond)) {
+		t.Errorf("Journal kept before a token was refilled")
+	}
+
+	if !s.keep("journal", now.Add(600*time.Millisecond)) {
+		t.Errorf("Journal disregarded after a token was refilled")
+	}
+
+	if newSampler(ChannelInterfaceConfig{}) != nil {
+		t.Errorf("Expected no sampler without SampleEvery, or RateLimits")
+	}
+}
+
+func TestChannelInterfaceSampled(t *testing.T) {
+	metrics := newTestMetrics()
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{
+		BufferSize:  4,
+		Metrics:     metrics,
+		SampleEvery: map[string]int{"commodity": 2}}.withDefaults())
+
+	for i := 0; i < 4; i++ {
+		ci.handleMessage(context.Background(), compress(t, commodity2Fixture))
+	}
+
+	if stats := ci.Stats(); stats.Parsed != 2 || stats.Sampled != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	if metrics.drops[DropSampled] != 2 {
+		t.Errorf("Unexpected drops: %v", metrics.drops)
+	}
+}
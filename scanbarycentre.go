@@ -0,0 +1,29 @@
+package EDDNClient
+
+// ScanBaryCentreMessage contains the orbital elements of a barycentre, the
+// point a pair of bodies, or stars orbit, sent to EDDN.  The barycentre
+// itself orbits a parent body, which BodyID identifies it within.  Distances
+// are in metres, angles in degrees, and the OrbitalPeriod in seconds.
+type ScanBaryCentreMessage struct {
+	AscendingNode      float64 `json:"AscendingNode"`
+	BodyID             int     `json:"BodyID"` // Required
+	Eccentricity       float64 `json:"Eccentricity"`
+	Event              string  `json:"event"` // Required
+	MeanAnomaly        float64 `json:"MeanAnomaly"`
+	OrbitalInclination float64 `json:"OrbitalInclination"`
+	OrbitalPeriod      float64 `json:"OrbitalPeriod"`
+	Periapsis          float64 `json:"Periapsis"`
+	SemiMajorAxis      float64 `json:"SemiMajorAxis"`
+	StarPos            StarPos `json:"StarPos"`       // Required
+	StarSystem         string  `json:"StarSystem"`    // Required
+	SystemAddress      int64   `json:"SystemAddress"` // Required
+	Timestamp          string  `json:"timestamp"`     // Required
+}
+
+// ScanBaryCentre is the high level type that contains the entire JSON
+// message.
+type ScanBaryCentre struct {
+	SchemaRef string                `json:"$schemaRef"`
+	Header    Header                `json:"header"`
+	Message   ScanBaryCentreMessage `json:"message"`
+}
@@ -0,0 +1,68 @@
+package EDDNClient
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	errMalformedSchema = errors.New("malformed schema reference")
+)
+
+// schemaHosts contains every host, and path EDDN has used for its schema
+// references.  EDDN originally lived at elite-markets.net before moving to
+// edcd.io, and both have been referenced over http, and https.
+var schemaHosts = [...]string{
+	"schemas.elite-markets.net/eddn/",
+	"eddn.edcd.io/schemas/",
+}
+
+// canonicalSchemaRef returns the stable key of a schema reference used to
+// find its decoder.  The reference is lowercased, and any trailing slashes
+// are trimmed, and if it's from one of the schemaHosts the scheme, and host
+// are stripped leaving only the schema name and version.  i.e.
+// "commodity/3", or "journal/1/test".  References with an unknown host are
+// lowercased, and trimmed, but otherwise untouched.
+func canonicalSchemaRef(ref string) string {
+	ref = strings.TrimRight(strings.ToLower(strings.TrimSpace(ref)), "/")
+	path := strings.TrimPrefix(strings.TrimPrefix(ref, "https://"), "http://")
+
+	for _, host := range schemaHosts {
+		if strings.HasPrefix(path, host) {
+			return strings.TrimPrefix(path, host)
+		}
+	}
+
+	return ref
+}
+
+// IsTestSchema reports whether ref is one of the "/test" variants of a
+// schema.  Messages using them are only delivered with IncludeTest, and may
+// be told apart by their SchemaRef.
+func IsTestSchema(ref string) bool {
+	return strings.HasSuffix(canonicalSchemaRef(ref), "/test")
+}
+
+// schemaKey parses a schema reference from either host into its name and
+// version.  The "/test" suffix is ignored so test messages share the key of
+// the schema they're testing.  i.e. "journal", 1 for
+// "https://eddn.edcd.io/schemas/journal/1/test".
+func schemaKey(ref string) (name string, version int, err error) {
+	key := strings.TrimSuffix(canonicalSchemaRef(ref), "/test")
+	parts := strings.Split(key, "/")
+
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", 0, fmt.Errorf("%w: %q", errMalformedSchema, ref)
+	}
+
+	version, err = strconv.Atoi(parts[1])
+
+	if err != nil || version < 1 {
+		return "", 0, fmt.Errorf("%w: %q has an invalid version",
+			errMalformedSchema, ref)
+	}
+
+	return parts[0], version, nil
+}
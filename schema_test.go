@@ -0,0 +1,79 @@
+package EDDNClient
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCanonicalSchemaRef(t *testing.T) {
+	tests := []struct {
+		ref      string
+		expected string
+	}{
+		{"http://schemas.elite-markets.net/eddn/commodity/3", "commodity/3"},
+		{"https://schemas.elite-markets.net/eddn/commodity/3", "commodity/3"},
+		{"https://eddn.edcd.io/schemas/commodity/3", "commodity/3"},
+		{"http://eddn.edcd.io/schemas/commodity/3", "commodity/3"},
+		{"https://eddn.edcd.io/schemas/commodity/3/", "commodity/3"},
+		{"https://EDDN.edcd.io/schemas/Commodity/3//", "commodity/3"},
+		{"https://eddn.edcd.io/schemas/journal/1/test", "journal/1/test"},
+		{"https://eddn.edcd.io/schemas/journal/1/test/", "journal/1/test"},
+		{"commodity/3", "commodity/3"},
+		{"http://example.com/commodity/3", "http://example.com/commodity/3"},
+	}
+
+	for _, test := range tests {
+		if got := canonicalSchemaRef(test.ref); got != test.expected {
+			t.Errorf("canonicalSchemaRef(%q) = %q, expected %q", test.ref, got,
+				test.expected)
+		}
+	}
+}
+
+func TestSchemaKey(t *testing.T) {
+	tests := []struct {
+		ref     string
+		name    string
+		version int
+	}{
+		{"http://schemas.elite-markets.net/eddn/commodity/3", "commodity", 3},
+		{"https://eddn.edcd.io/schemas/journal/1", "journal", 1},
+		{"https://eddn.edcd.io/schemas/journal/1/test", "journal", 1},
+		{"https://eddn.edcd.io/schemas/commodity/4", "commodity", 4},
+		{"http://eddn.edcd.io/schemas/commodity/3/", "commodity", 3},
+		{"https://schemas.elite-markets.net/eddn/Journal/1/Test", "journal", 1},
+	}
+
+	for _, test := range tests {
+		name, version, err := schemaKey(test.ref)
+
+		if err != nil {
+			t.Errorf("schemaKey(%q) unexpected error: %v", test.ref, err)
+			continue
+		}
+
+		if name != test.name || version != test.version {
+			t.Errorf("schemaKey(%q) = %q, %d, expected %q, %d", test.ref, name,
+				version, test.name, test.version)
+		}
+	}
+}
+
+func TestSchemaKeyMalformed(t *testing.T) {
+	refs := []string{
+		"",
+		"https://eddn.edcd.io/schemas/",
+		"https://eddn.edcd.io/schemas/commodity",
+		"https://eddn.edcd.io/schemas/commodity/",
+		"https://eddn.edcd.io/schemas/commodity/three",
+		"https://eddn.edcd.io/schemas/commodity/0",
+		"https://eddn.edcd.io/schemas/commodity/3/extra",
+		"http://example.com/commodity/3",
+	}
+
+	for _, ref := range refs {
+		if _, _, err := schemaKey(ref); !errors.Is(err, errMalformedSchema) {
+			t.Errorf("schemaKey(%q) expected malformed error, got %v", ref, err)
+		}
+	}
+}
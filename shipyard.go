@@ -14,3 +14,48 @@ type Shipyard struct {
 	Header    Header          `json:"header"`
 	Message   ShipyardMessage `json:"message"`
 }
+
+// Shipyard1Ship describes a single ship sent in a version 1 message.  The
+// price was dropped in version 2.
+type Shipyard1Ship struct {
+	Name  string `json:"name"` // Required
+	Price int    `json:"price,omitempty"`
+}
+
+// Shipyard1Message contains the shipyard data sent to EDDN using the
+// version 1 schema.  Some uploaders omitted the station name entirely so it
+// may be empty.
+type Shipyard1Message struct {
+	Ships       []Shipyard1Ship `json:"ships"` // Required
+	StationName string          `json:"stationName,omitempty"`
+	SystemName  string          `json:"systemName"` // Required
+	Timestamp   string          `json:"timestamp"`  // Required
+}
+
+// Shipyard1 is the high level type that contains the entire version 1 JSON
+// message, as returned by ParseMessage.  The ChannelInterface sends it on
+// ShipyardChan converted to a Shipyard, and only a handler registered with On
+// for Shipyard1 is given the prices.
+type Shipyard1 struct {
+	SchemaRef string           `json:"$schemaRef"`
+	Header    Header           `json:"header"`
+	Message   Shipyard1Message `json:"message"`
+}
+
+// Shipyard converts a version 1 message into the current Shipyard type.
+// Only the ship names are kept so the ships can be iterated the same way as
+// version 2 messages.  The schema reference is left untouched so the
+// receiver can still tell which version the data originally came from.
+func (s Shipyard1) Shipyard() Shipyard {
+	ships := make([]string, 0, len(s.Message.Ships))
+
+	for _, ship := range s.Message.Ships {
+		ships = append(ships, ship.Name)
+	}
+
+	return Shipyard{s.SchemaRef, s.Header, ShipyardMessage{
+		Ships:       ships,
+		StationName: s.Message.StationName,
+		SystemName:  s.Message.SystemName,
+		Timestamp:   s.Message.Timestamp}}
+}
@@ -0,0 +1,46 @@
+package EDDNClient
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	errSourceDone = errors.New("frame source has no more frames")
+)
+
+// FrameSource provides the frames received by a ChannelInterface in place of
+// a relay, for receivers bridging EDDN some other way.  i.e. from a message
+// queue.  See ChannelInterfaceConfig.Source.
+type FrameSource interface {
+	// Next returns the next frame, zlib compressed as it is on the wire, or
+	// plain JSON.  It returns io.EOF once there are no more frames, which
+	// closes the ChannelInterface.  Any other error is treated as a lost
+	// connection, and Next is tried again after the reconnect delay.
+	Next() ([]byte, error)
+}
+
+// sourceTransport is a transport receiving frames from a FrameSource.
+type sourceTransport struct {
+	source FrameSource
+}
+
+func (t sourceTransport) recv() (frame string, err error) {
+	data, err := t.source.Next()
+
+	if errors.Is(err, io.EOF) {
+		return "", errSourceDone
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// close does nothing, as the same FrameSource is used again when
+// reconnecting.  The receiver owns the FrameSource, and closes it itself.
+func (t sourceTransport) close() error {
+	return nil
+}
@@ -0,0 +1,65 @@
+package EDDNClient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// testSource is a FrameSource returning each of frames, or errors in turn.
+type testSource struct {
+	frames [][]byte
+	errs   []error
+}
+
+func (s *testSource) Next() ([]byte, error) {
+	if len(s.frames) == 0 {
+		return nil, io.EOF
+	}
+
+	frame, err := s.frames[0], s.errs[0]
+	s.frames, s.errs = s.frames[1:], s.errs[1:]
+
+	return frame, err
+}
+
+func TestChannelInterfaceSource(t *testing.T) {
+	source := &testSource{
+		frames: [][]byte{CompressFrame(commodity2Fixture), nil,
+			[]byte(commodity1Fixture)},
+		errs: []error{nil, errors.New("bridge lost"), nil}}
+
+	ci, err := NewChannelInterfaceContext(context.Background(), FilterNone,
+		ChannelInterfaceConfig{Source: source, ReconnectMinDelay: time.Millisecond})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var commodities []Commodity
+
+	timeout := time.After(5 * time.Second)
+
+	for done := false; !done; {
+		select {
+		case msg, ok := <-ci.CommodityChan:
+			if ok {
+				commodities = append(commodities, msg)
+			}
+		case <-ci.Done:
+			done = true
+		case <-timeout:
+			t.Fatalf("ChannelInterface was not closed once the source ended")
+		}
+	}
+
+	if len(commodities) != 2 {
+		t.Fatalf("Expected 2 commodities, got %d", len(commodities))
+	}
+
+	if stats := ci.Stats(); stats.Received != 2 || stats.Reconnects != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
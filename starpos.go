@@ -0,0 +1,86 @@
+package EDDNClient
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// StarPos contains the galactic coordinates of a system in light years
+// relative to Sol.  EDDN sends these as a three element array of [x, y, z].
+type StarPos struct {
+	X float64
+	Y float64
+	Z float64
+}
+
+// UnmarshalJSON reads a StarPos from its [x, y, z] array form.  A JSON null
+// leaves p unchanged, as with the types in encoding/json.
+func (p *StarPos) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var coords []float64
+
+	if err := json.Unmarshal(data, &coords); err != nil {
+		return err
+	}
+
+	pos, err := starPosFromSlice(coords)
+
+	if err != nil {
+		return err
+	}
+
+	*p = pos
+
+	return nil
+}
+
+// MarshalJSON writes a StarPos in its [x, y, z] array form.
+func (p StarPos) MarshalJSON() ([]byte, error) {
+	return json.Marshal([3]float64{p.X, p.Y, p.Z})
+}
+
+func starPosFromSlice(coords []float64) (pos StarPos, err error) {
+	if len(coords) != 3 {
+		return StarPos{}, fmt.Errorf("StarPos must contain 3 coordinates, got %d",
+			len(coords))
+	}
+
+	return StarPos{coords[0], coords[1], coords[2]}, nil
+}
+
+// starPosHook is a mapstructure decode hook that converts the [x, y, z]
+// array found in journal messages into a StarPos.
+func starPosHook(from reflect.Type, to reflect.Type,
+	data interface{}) (interface{}, error) {
+
+	if to != reflect.TypeOf(StarPos{}) {
+		return data, nil
+	}
+
+	switch values := data.(type) {
+	case []float64:
+		return starPosFromSlice(values)
+
+	case []interface{}:
+		coords := make([]float64, 0, len(values))
+
+		for _, value := range values {
+			coord, ok := value.(float64)
+
+			if !ok {
+				return nil, fmt.Errorf("StarPos coordinate %v is not a number",
+					value)
+			}
+
+			coords = append(coords, coord)
+		}
+
+		return starPosFromSlice(coords)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,88 @@
+package EDDNClient
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStarPosUnmarshalJSON(t *testing.T) {
+	var pos StarPos
+
+	if err := json.Unmarshal([]byte(`[-77.0, -146.78125, 344.125]`), &pos); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if pos.X != -77.0 || pos.Y != -146.78125 || pos.Z != 344.125 {
+		t.Errorf("Unexpected StarPos: %+v", pos)
+	}
+
+	out, err := json.Marshal(pos)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if string(out) != `[-77,-146.78125,344.125]` {
+		t.Errorf("Unexpected JSON: %s", out)
+	}
+}
+
+func TestStarPosUnmarshalJSONShort(t *testing.T) {
+	for _, data := range []string{`[]`, `[1.0, 2.0]`, `[1.0, 2.0, 3.0, 4.0]`,
+		`"1.0, 2.0, 3.0"`} {
+		var pos StarPos
+
+		if err := json.Unmarshal([]byte(data), &pos); err == nil {
+			t.Errorf("Expected error for %s, got %+v", data, pos)
+		}
+	}
+}
+
+func TestStarPosUnmarshalJSONNull(t *testing.T) {
+	pos := StarPos{1.0, 2.0, 3.0}
+
+	if err := json.Unmarshal([]byte(`null`), &pos); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if pos != (StarPos{1.0, 2.0, 3.0}) {
+		t.Errorf("Expected StarPos unchanged by null, got %+v", pos)
+	}
+
+	var system struct {
+		StarPos StarPos `json:"StarPos"`
+	}
+
+	if err := json.Unmarshal([]byte(`{"StarPos": null}`), &system); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if system.StarPos != (StarPos{}) {
+		t.Errorf("Expected zero StarPos, got %+v", system.StarPos)
+	}
+}
+
+func TestStarPosMissing(t *testing.T) {
+	var system struct {
+		StarPos StarPos `json:"StarPos"`
+	}
+
+	if err := json.Unmarshal([]byte(`{}`), &system); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if system.StarPos != (StarPos{}) {
+		t.Errorf("Expected zero StarPos, got %+v", system.StarPos)
+	}
+}
+
+func TestStarPosJournalShort(t *testing.T) {
+	_, err := handleJournalMessage(map[string]interface{}{
+		"event":      "FSDJump",
+		"StarSystem": "Pleione",
+		"StarPos":    []interface{}{-77.0, -146.78125}})
+
+	if err == nil {
+		t.Errorf("Expected error for a short StarPos")
+	}
+}
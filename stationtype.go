@@ -0,0 +1,80 @@
+package EDDNClient
+
+import (
+	"strings"
+)
+
+// StationType is the type of a station as reported by the game.  Frontier
+// has renamed some over time, so StationType should be compared rather than
+// the raw strings.
+type StationType int
+
+// Known station types.  Anything else parses as StationTypeUnknown.
+const (
+	StationTypeUnknown          StationType = iota // Not a known station type
+	StationTypeCoriolis                            // Coriolis starport
+	StationTypeOrbis                               // Orbis starport
+	StationTypeOcellus                             // Ocellus starport, once called Bernal
+	StationTypeOutpost                             // Outpost
+	StationTypeAsteroidBase                        // Asteroid base
+	StationTypeMegaShip                            // Megaship
+	StationTypeFleetCarrier                        // Fleet carrier
+	StationTypeCraterOutpost                       // Planetary outpost
+	StationTypeCraterPort                          // Planetary port
+	StationTypeOnFootSettlement                    // Odyssey settlement
+	StationTypeSurfaceStation                      // Other surface stations
+)
+
+// stationTypeNames contains the name the game uses for each station type.
+var stationTypeNames = map[StationType]string{
+	StationTypeCoriolis:         "Coriolis",
+	StationTypeOrbis:            "Orbis",
+	StationTypeOcellus:          "Ocellus",
+	StationTypeOutpost:          "Outpost",
+	StationTypeAsteroidBase:     "AsteroidBase",
+	StationTypeMegaShip:         "MegaShip",
+	StationTypeFleetCarrier:     "FleetCarrier",
+	StationTypeCraterOutpost:    "CraterOutpost",
+	StationTypeCraterPort:       "CraterPort",
+	StationTypeOnFootSettlement: "OnFootSettlement",
+	StationTypeSurfaceStation:   "SurfaceStation",
+}
+
+// stationTypes maps each normalized station type name, including the old
+// names, to its StationType.
+var stationTypes = map[string]StationType{
+	"bernal": StationTypeOcellus,
+}
+
+func init() {
+	for stationType, name := range stationTypeNames {
+		stationTypes[normalizeStationType(name)] = stationType
+	}
+}
+
+// normalizeStationType lowercases name, and removes any spaces, or
+// underscores.  i.e. "Fleet Carrier" becomes "fleetcarrier".
+func normalizeStationType(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '_' {
+			return -1
+		}
+
+		return r
+	}, strings.ToLower(strings.TrimSpace(name)))
+}
+
+// ParseStationType returns the StationType with the given name, ignoring case,
+// spaces, and underscores.  Unknown names return StationTypeUnknown.
+func ParseStationType(name string) StationType {
+	return stationTypes[normalizeStationType(name)]
+}
+
+// String returns the name the game uses for t, or "Unknown".
+func (t StationType) String() string {
+	if name, ok := stationTypeNames[t]; ok {
+		return name
+	}
+
+	return "Unknown"
+}
@@ -0,0 +1,33 @@
+package EDDNClient
+
+import (
+	"testing"
+)
+
+func TestParseStationType(t *testing.T) {
+	tests := []struct {
+		name string
+		want StationType
+	}{
+		{"Coriolis", StationTypeCoriolis},
+		{"coriolis", StationTypeCoriolis},
+		{"Bernal", StationTypeOcellus},
+		{"Ocellus", StationTypeOcellus},
+		{"Fleet Carrier", StationTypeFleetCarrier},
+		{"FleetCarrier", StationTypeFleetCarrier},
+		{" CraterOutpost ", StationTypeCraterOutpost},
+		{"On_Foot_Settlement", StationTypeOnFootSettlement},
+		{"", StationTypeUnknown},
+		{"Space Elevator", StationTypeUnknown},
+	}
+
+	for _, test := range tests {
+		if got := ParseStationType(test.name); got != test.want {
+			t.Errorf("ParseStationType(%q) = %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	if StationTypeOcellus.String() != "Ocellus" || StationType(-1).String() != "Unknown" {
+		t.Errorf("Unexpected names: %v, %v", StationTypeOcellus, StationType(-1))
+	}
+}
@@ -0,0 +1,127 @@
+package EDDNClient
+
+// ConnectionState describes the connection of a ChannelInterface to the
+// relay.
+type ConnectionState int32
+
+// The states a ChannelInterface may be in.
+const (
+	StateConnected    ConnectionState = iota // Subscribed to the relay
+	StateReconnecting                        // Waiting to reconnect to the relay
+	StateStopped                             // Closed, or cancelled
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateStopped:
+		return "stopped"
+	}
+
+	return "unknown"
+}
+
+// Stats contains the statistics of a ChannelInterface since it was created.
+type Stats struct {
+	Received    uint64          // Messages received from the relay
+	Parsed      uint64          // Messages parsed successfully
+	ParseErrors uint64          // Messages that failed to parse
+	Invalid     uint64          // Messages missing required fields (Validate)
+	Unsupported uint64          // Messages using a schema that isn't supported
+	Filtered    uint64          // Messages disregarded by the header, or scan filters
+	Duplicates  uint64          // Messages disregarded by EnableDedup
+	Sampled     uint64          // Messages disregarded by SampleEvery, or RateLimits
+	Dropped     uint64          // Messages dropped due to the OverflowPolicy
+	Reconnects  uint64          // Successful reconnections to the relay
+	Oversized   uint64          // Messages larger than the MaxMessageSize (also ParseErrors)
+	Smallest    uint64          // Smallest message seen once decompressed, in bytes
+	Largest     uint64          // Largest message seen once decompressed, in bytes
+	State       ConnectionState // Current state of the connection
+	Relay       string          // Address of the relay in use
+}
+
+// Stats returns the current statistics of ci.  It's safe to call from any
+// goroutine, but as each counter is read separately they may be updated
+// while Stats is running.
+func (ci *ChannelInterface) Stats() Stats {
+	return Stats{
+		Received:    ci.received.Load(),
+		Parsed:      ci.parsed.Load(),
+		ParseErrors: ci.parseErrors.Load(),
+		Invalid:     ci.invalid.Load(),
+		Unsupported: ci.unsupported.Load(),
+		Filtered:    ci.filtered.Load(),
+		Duplicates:  ci.duplicates.Load(),
+		Sampled:     ci.sampled.Load(),
+		Dropped:     ci.dropped.Load(),
+		Reconnects:  ci.reconnects.Load(),
+		Oversized:   ci.oversized.Load(),
+		Smallest:    ci.smallest.Load(),
+		Largest:     ci.largest.Load(),
+		State:       ConnectionState(ci.state.Load()),
+		Relay:       ci.config.relays()[ci.relay.Load()]}
+}
+
+// observeSize records the size of a decompressed message for the Smallest,
+// and Largest Stats.  Messages larger than the MaxMessageSize are never
+// decompressed in full, so they're only counted as Oversized.
+func (ci *ChannelInterface) observeSize(output []byte) {
+	size := uint64(len(output))
+
+	for {
+		smallest := ci.smallest.Load()
+
+		if (smallest != 0 && smallest <= size) ||
+			ci.smallest.CompareAndSwap(smallest, size) {
+			break
+		}
+	}
+
+	for {
+		largest := ci.largest.Load()
+
+		if largest >= size || ci.largest.CompareAndSwap(largest, size) {
+			break
+		}
+	}
+}
+
+// maxUnhandledEvents is the most journal event names UnhandledEvents counts.
+// Names are sent by uploaders, so the map must be bounded.
+const maxUnhandledEvents = 256
+
+// UnhandledEvents returns how many times each journal event that has no
+// JournalEventDecoder was received, by the event's name.  Only the first 256
+// names seen are counted, which is far more than the game has.  It's safe
+// to call from any goroutine, and the map returned is a copy.
+func (ci *ChannelInterface) UnhandledEvents() map[string]uint64 {
+	ci.unhandledLock.Lock()
+	defer ci.unhandledLock.Unlock()
+
+	events := make(map[string]uint64, len(ci.unhandledEvents))
+
+	for name, count := range ci.unhandledEvents {
+		events[name] = count
+	}
+
+	return events
+}
+
+// countUnhandledEvent counts a journal event named name that has no
+// JournalEventDecoder.
+func (ci *ChannelInterface) countUnhandledEvent(name string) {
+	ci.unhandledLock.Lock()
+	defer ci.unhandledLock.Unlock()
+
+	if ci.unhandledEvents == nil {
+		ci.unhandledEvents = make(map[string]uint64)
+	}
+
+	if _, ok := ci.unhandledEvents[name]; ok ||
+		len(ci.unhandledEvents) < maxUnhandledEvents {
+		ci.unhandledEvents[name]++
+	}
+}
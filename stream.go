@@ -0,0 +1,254 @@
+package EDDNClient
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	errUnknownFraming = errors.New("unknown framing")
+)
+
+// LineError is given to the ParseStream callback for a line that failed to
+// parse, so the line may be found in the stream.
+type LineError struct {
+	Line int   // Line, or frame number, starting at 1
+	Err  error // Error parsing the message on the line
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// ParseStream parses every message read from r, calling fn with the result
+// of each one in turn.  Messages are handled as they're read so the entire
+// stream is never held in memory.
+//
+// r must contain one message per line, which is the format of the captured
+// EDDN archives, and blank lines are skipped.  Each line is either plain
+// JSON, or zlib compressed as it is on the wire.  A compressed message is read
+// to the end of its zlib stream, so it may contain newlines, and anything
+// between the end of the stream and the next newline is ignored.  Archives
+// that are compressed as a whole should be decompressed first, i.e. with
+// gzip.NewReader.  Plain lines longer than DefaultMaxMessageSize stop the
+// stream with a *MessageTooLargeError.
+//
+// Errors parsing a single message are given to fn as a *LineError along with
+// its result, and the stream continues with the next line.  ParseStream only
+// returns an error if reading from r fails.
+func ParseStream(r io.Reader, fn func(result ParseResult, err error)) error {
+	br := bufio.NewReader(r)
+
+	for n := 1; ; n++ {
+		header, err := br.Peek(2)
+
+		if len(header) == 0 {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		var result ParseResult
+
+		if isZlib(header) {
+			result, err = parseZlibLine(br)
+		} else {
+			var line []byte
+
+			line, err = readLine(br)
+
+			if err != nil {
+				return err
+			}
+
+			line = bytes.TrimSpace(line)
+
+			if len(line) == 0 {
+				continue
+			}
+
+			result, err = defaultParser.parseJSONRaw(line)
+		}
+
+		if err != nil {
+			err = &LineError{Line: n, Err: err}
+		}
+
+		fn(result, err)
+	}
+}
+
+// readLine reads the next line from r, including its newline if it has one.
+// io.EOF is only returned if r is empty.
+func readLine(r *bufio.Reader) (line []byte, err error) {
+	for {
+		var chunk []byte
+
+		chunk, err = r.ReadSlice('\n')
+		line = append(line, chunk...)
+
+		if len(line) > DefaultMaxMessageSize {
+			return nil, &MessageTooLargeError{DefaultMaxMessageSize}
+		}
+
+		if err != bufio.ErrBufferFull {
+			break
+		}
+	}
+
+	if errors.Is(err, io.EOF) && len(line) > 0 {
+		err = nil
+	}
+
+	return line, err
+}
+
+// parseZlibLine parses the zlib compressed message at the start of r.  r is
+// read to the end of the zlib stream, and then on to the end of the line.  A
+// message that is too large is still read to the end of its stream, but after
+// any other error the stream's end can't be found, so the rest of the line is
+// skipped instead.
+func parseZlibLine(r *bufio.Reader) (result ParseResult, err error) {
+	defer func() {
+		if _, skipErr := readLine(r); err == nil && skipErr != nil &&
+			!errors.Is(skipErr, io.EOF) {
+			err = skipErr
+		}
+	}()
+
+	// The zlib reader reads exactly to the end of the stream as r is an
+	// io.ByteReader.
+	zr, err := getZlibReader(r)
+	defer putZlibReader(zr)
+
+	if err != nil {
+		return ParseResult{}, &categoryError{ErrDecompress, err}
+	}
+
+	output, err := defaultParser.decompress(zr, 0)
+
+	if err != nil {
+		var tooLarge *MessageTooLargeError
+
+		if errors.As(err, &tooLarge) {
+			io.Copy(io.Discard, zr)
+		}
+
+		return ParseResult{}, err
+	}
+
+	return defaultParser.parseJSONRaw(output)
+}
+
+// ParseFile is the same as ParseStream, but reads the archive at path.
+// Archives with a ".gz" extension are decompressed as they're read.
+func ParseFile(path string, fn func(result ParseResult, err error)) error {
+	f, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	var r io.Reader = f
+
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+
+		if err != nil {
+			return err
+		}
+
+		defer gz.Close()
+
+		r = gz
+	}
+
+	return ParseStream(r, fn)
+}
+
+// Framing is how the frames of a stream given to ParseFromReaderFramed are
+// separated.
+type Framing int
+
+// The framings supported by ParseFromReaderFramed.
+const (
+	// One frame per line.  See ParseStream.
+	FramingLines Framing = iota
+
+	// Each frame follows its length as a big endian uint32.
+	FramingLengthPrefix
+)
+
+// ParseFromReaderFramed is the same as ParseStream, but allows the receiver
+// to choose the framing of r.  i.e. to parse frames piped from another tool
+// on os.Stdin.  Length prefixed frames may contain anything, so each one may
+// be plain JSON, or zlib, or gzip compressed.  Frames longer than
+// DefaultMaxMessageSize stop the stream with a *MessageTooLargeError, as does
+// a length prefix claiming one is.  An unknown framing is an error.
+func ParseFromReaderFramed(r io.Reader, framing Framing,
+	fn func(result ParseResult, err error)) error {
+
+	switch framing {
+	case FramingLines:
+		return ParseStream(r, fn)
+
+	case FramingLengthPrefix:
+		return parseLengthPrefixed(bufio.NewReader(r), fn)
+	}
+
+	return fmt.Errorf("%w: %d", errUnknownFraming, framing)
+}
+
+// parseLengthPrefixed parses each length prefixed frame read from r.  See
+// ParseFromReaderFramed.
+func parseLengthPrefixed(r io.Reader,
+	fn func(result ParseResult, err error)) error {
+	for n := 1; ; n++ {
+		var size uint32
+
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		if int64(size) > DefaultMaxMessageSize {
+			return &MessageTooLargeError{DefaultMaxMessageSize}
+		}
+
+		frame := make([]byte, size)
+
+		if _, err := io.ReadFull(r, frame); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+
+			return err
+		}
+
+		result, err := defaultParser.parseJSON(string(frame))
+
+		if err != nil {
+			err = &LineError{Line: n, Err: err}
+		}
+
+		fn(result, err)
+	}
+}
@@ -0,0 +1,212 @@
+package EDDNClient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// oneLine joins a multi-line JSON fixture onto a single line.
+func oneLine(fixture string) string {
+	return strings.Join(strings.Fields(fixture), " ")
+}
+
+func TestParseStream(t *testing.T) {
+	stream := strings.Join([]string{
+		oneLine(commodity2Fixture),
+		"",
+		"not a message",
+		oneLine(navRouteFixture),
+	}, "\n")
+
+	var results []ParseResult
+	var errs []error
+
+	err := ParseStream(strings.NewReader(stream), func(result ParseResult, err error) {
+		results = append(results, result)
+		errs = append(errs, err)
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+
+	if _, ok := results[0].Message.(Commodity); !ok || errs[0] != nil {
+		t.Errorf("Expected Commodity, got %T, %v", results[0].Message, errs[0])
+	}
+
+	if errs[1] == nil {
+		t.Errorf("Expected error parsing an invalid line")
+	}
+
+	if _, ok := results[2].Message.(NavRoute); !ok || errs[2] != nil {
+		t.Errorf("Expected NavRoute, got %T, %v", results[2].Message, errs[2])
+	}
+}
+
+func TestParseStreamTooLarge(t *testing.T) {
+	stream := strings.Repeat(" ", DefaultMaxMessageSize+1)
+
+	err := ParseStream(strings.NewReader(stream), func(result ParseResult, err error) {
+		t.Errorf("Unexpected result: %+v, %v", result, err)
+	})
+
+	var tooLarge *MessageTooLargeError
+
+	if !errors.As(err, &tooLarge) {
+		t.Errorf("Expected MessageTooLargeError, got %v", err)
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	// Compressed messages are read to the end of their zlib stream, so pad
+	// the message until its compressed form contains a newline.
+	compressed := CompressFrame(commodity2Fixture)
+
+	for pad := " "; bytes.IndexByte(compressed, '\n') < 0; pad += " " {
+		compressed = CompressFrame(commodity2Fixture + pad)
+	}
+
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(oneLine(navRouteFixture) + "\n\nnot a message\n"))
+	w.Write(append(compressed, '\n'))
+	w.Write([]byte(oneLine(navRouteFixture) + "\n"))
+	w.Close()
+
+	path := filepath.Join(t.TempDir(), "archive.jsonl.gz")
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var results []ParseResult
+	var errs []error
+
+	err := ParseFile(path, func(result ParseResult, err error) {
+		results = append(results, result)
+		errs = append(errs, err)
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+
+	if _, ok := results[0].Message.(NavRoute); !ok || errs[0] != nil {
+		t.Errorf("Expected NavRoute, got %T, %v", results[0].Message, errs[0])
+	}
+
+	var lineErr *LineError
+
+	if !errors.As(errs[1], &lineErr) || lineErr.Line != 3 {
+		t.Errorf("Expected LineError on line 3, got %v", errs[1])
+	}
+
+	if _, ok := results[2].Message.(Commodity); !ok || errs[2] != nil {
+		t.Errorf("Expected Commodity, got %T, %v", results[2].Message, errs[2])
+	}
+
+	if _, ok := results[3].Message.(NavRoute); !ok || errs[3] != nil {
+		t.Errorf("Expected NavRoute after the compressed line, got %T, %v",
+			results[3].Message, errs[3])
+	}
+
+	if err := ParseFile(filepath.Join(t.TempDir(), "missing"), nil); err == nil {
+		t.Errorf("Expected an error for a missing file")
+	}
+}
+
+func TestParseFromReaderFramedLengthPrefix(t *testing.T) {
+	var stream bytes.Buffer
+
+	for _, frame := range [][]byte{
+		CompressFrame(commodity2Fixture),
+		[]byte("not a message"),
+		[]byte(navRouteFixture),
+	} {
+		binary.Write(&stream, binary.BigEndian, uint32(len(frame)))
+		stream.Write(frame)
+	}
+
+	var results []ParseResult
+	var errs []error
+
+	err := ParseFromReaderFramed(&stream, FramingLengthPrefix, func(result ParseResult, err error) {
+		results = append(results, result)
+		errs = append(errs, err)
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+
+	if _, ok := results[0].Message.(Commodity); !ok || errs[0] != nil {
+		t.Errorf("Expected Commodity, got %T, %v", results[0].Message, errs[0])
+	}
+
+	var lineErr *LineError
+
+	if !errors.As(errs[1], &lineErr) || lineErr.Line != 2 {
+		t.Errorf("Expected LineError for frame 2, got %v", errs[1])
+	}
+
+	if _, ok := results[2].Message.(NavRoute); !ok || errs[2] != nil {
+		t.Errorf("Expected NavRoute, got %T, %v", results[2].Message, errs[2])
+	}
+}
+
+func TestParseFromReaderFramedLengthPrefixTruncated(t *testing.T) {
+	stream := []byte{0, 0, 0, 10, '{'}
+
+	err := ParseFromReaderFramed(bytes.NewReader(stream), FramingLengthPrefix,
+		func(result ParseResult, err error) {
+			t.Errorf("Unexpected result: %+v, %v", result, err)
+		})
+
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected ErrUnexpectedEOF, got %v", err)
+	}
+
+	stream = []byte{0xff, 0xff, 0xff, 0xff}
+
+	err = ParseFromReaderFramed(bytes.NewReader(stream), FramingLengthPrefix,
+		func(result ParseResult, err error) {
+			t.Errorf("Unexpected result: %+v, %v", result, err)
+		})
+
+	var tooLarge *MessageTooLargeError
+
+	if !errors.As(err, &tooLarge) {
+		t.Errorf("Expected MessageTooLargeError, got %v", err)
+	}
+}
+
+func TestParseFromReaderFramedUnknown(t *testing.T) {
+	err := ParseFromReaderFramed(strings.NewReader(navRouteFixture), Framing(-1),
+		func(result ParseResult, err error) {
+			t.Errorf("Unexpected result: %+v, %v", result, err)
+		})
+
+	if !errors.Is(err, errUnknownFraming) {
+		t.Errorf("Expected errUnknownFraming, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package EDDNClient
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Subscription receives a copy of every message delivered by a
+// ChannelInterface.  See Subscribe.
+type Subscription struct {
+	C <-chan interface{} // Channel every message is sent on
+
+	ci       *ChannelInterface
+	c        chan interface{}
+	policy   OverflowPolicy
+	done     chan struct{} // Closed by Unsubscribe to unblock any send
+	doneOnce sync.Once
+	dropped  atomic.Uint64
+}
+
+// Subscribe returns a new Subscription, which receives each message that
+// isn't taken by a handler registered with On, or OnDefault, on C.  Each
+// subscription has its own buffer of the given size, and OverflowPolicy
+// applied when it's full, so several independent consumers may each read
+// every message.  With OverflowBlock a slow subscriber delays every other,
+// and the ChannelInterface itself, so consumers that can't keep up should
+// drop messages instead.
+//
+// While there are any subscriptions messages aren't sent on the channels of
+// the ChannelInterface.  C is closed once the ChannelInterface is, or by
+// Unsubscribe.
+func (ci *ChannelInterface) Subscribe(buffer int,
+	policy OverflowPolicy) *Subscription {
+
+	if buffer < 0 {
+		buffer = 0
+	}
+
+	c := make(chan interface{}, buffer)
+
+	s := &Subscription{
+		C:      c,
+		ci:     ci,
+		c:      c,
+		policy: policy,
+		done:   make(chan struct{})}
+
+	ci.subscriptionLock.Lock()
+	defer ci.subscriptionLock.Unlock()
+
+	// Nothing more will be delivered once closed.
+	if ci.subscriptionsClosed {
+		s.doneOnce.Do(func() { close(s.done) })
+		close(c)
+		return s
+	}
+
+	if ci.subscriptions == nil {
+		ci.subscriptions = make(map[*Subscription]bool)
+	}
+
+	ci.subscriptions[s] = true
+
+	return s
+}
+
+// Unsubscribe stops s from receiving messages, and closes C.  Messages
+// already buffered may still be read.  It may be called more than once.
+func (s *Subscription) Unsubscribe() {
+	// Unblock any send in progress first, which holds the lock.
+	s.doneOnce.Do(func() { close(s.done) })
+
+	s.ci.subscriptionLock.Lock()
+	defer s.ci.subscriptionLock.Unlock()
+
+	if s.ci.subscriptions[s] {
+		delete(s.ci.subscriptions, s)
+		close(s.c)
+	}
+}
+
+// Dropped returns the number of messages dropped by the OverflowPolicy of s.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// publish sends msg to every subscription of ci.  It reports false if there
+// are none.
+func (ci *ChannelInterface) publish(ctx context.Context, msg interface{}) bool {
+	ci.subscriptionLock.RLock()
+	defer ci.subscriptionLock.RUnlock()
+
+	if len(ci.subscriptions) == 0 {
+		return false
+	}
+
+	for s := range ci.subscriptions {
+		for dropped := s.send(ctx, msg); dropped > 0; dropped-- {
+			s.dropped.Add(1)
+			ci.dropped.Add(1)
+			ci.metrics().ObserveDrop(DropOverflow)
+		}
+	}
+
+	return true
+}
+
+// send sends msg on s applying its OverflowPolicy, and returns the number of
+// messages dropped.
+func (s *Subscription) send(ctx context.Context, msg interface{}) (dropped int) {
+	switch s.policy {
+	case OverflowDropNewest:
+		select {
+		case s.c <- msg:
+			return 0
+		default:
+			return 1
+		}
+
+	case OverflowDropOldest:
+		for {
+			select {
+			case s.c <- msg:
+				return dropped
+			default:
+			}
+
+			dropped++
+
+			// Unbuffered channels have nothing to drop but the new message.
+			select {
+			case <-s.c:
+			default:
+				return dropped
+			}
+		}
+
+	default:
+		select {
+		case s.c <- msg:
+		case <-s.done:
+		case <-ctx.Done():
+		}
+
+		return 0
+	}
+}
+
+// closeSubscriptions closes every subscription of ci once it's closed.
+func (ci *ChannelInterface) closeSubscriptions() {
+	ci.subscriptionLock.Lock()
+	defer ci.subscriptionLock.Unlock()
+
+	for s := range ci.subscriptions {
+		close(s.c)
+	}
+
+	ci.subscriptions = nil
+	ci.subscriptionsClosed = true
+}
@@ -0,0 +1,113 @@
+package EDDNClient
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestChannelInterfaceSubscribe(t *testing.T) {
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{}.withDefaults())
+
+	archiver := ci.Subscribe(4, OverflowBlock)
+	tracker := ci.Subscribe(0, OverflowDropNewest)
+
+	// Nothing reads the unbuffered tracker so it drops the message, while
+	// the channels aren't sent on at all.
+	ci.handleMessage(context.Background(), compress(t, commodity2Fixture))
+	ci.handleMessage(context.Background(), compress(t, navRouteFixture))
+
+	if len(archiver.C) != 2 {
+		t.Fatalf("Expected 2 messages for the archiver, got %d", len(archiver.C))
+	}
+
+	if _, ok := (<-archiver.C).(Commodity); !ok {
+		t.Errorf("Expected the commodity first")
+	}
+
+	if _, ok := (<-archiver.C).(NavRoute); !ok {
+		t.Errorf("Expected the route second")
+	}
+
+	if tracker.Dropped() != 2 || archiver.Dropped() != 0 || ci.Dropped() != 2 {
+		t.Errorf("Unexpected drops: tracker %d, archiver %d, total %d",
+			tracker.Dropped(), archiver.Dropped(), ci.Dropped())
+	}
+
+	tracker.Unsubscribe()
+	tracker.Unsubscribe()
+
+	if _, ok := <-tracker.C; ok {
+		t.Errorf("Expected C to be closed by Unsubscribe")
+	}
+
+	ci.handleMessage(context.Background(), compress(t, commodity2Fixture))
+
+	if len(archiver.C) != 1 {
+		t.Errorf("Expected the archiver to still receive messages")
+	}
+}
+
+func TestChannelInterfaceSubscribeDropOldest(t *testing.T) {
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{}.withDefaults())
+	s := ci.Subscribe(1, OverflowDropOldest)
+
+	ci.handleMessage(context.Background(), compress(t, commodity2Fixture))
+	ci.handleMessage(context.Background(), compress(t, navRouteFixture))
+
+	if _, ok := (<-s.C).(NavRoute); !ok || s.Dropped() != 1 {
+		t.Errorf("Expected the newest message to be kept, dropped %d", s.Dropped())
+	}
+}
+
+func TestChannelInterfaceUnsubscribeWhileBlocked(t *testing.T) {
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{}.withDefaults())
+	s := ci.Subscribe(0, OverflowBlock)
+
+	handled := make(chan bool)
+
+	go func() {
+		ci.handleMessage(context.Background(), compress(t, commodity2Fixture))
+		close(handled)
+	}()
+
+	// Give the send a chance to block before unsubscribing.
+	time.Sleep(10 * time.Millisecond)
+	s.Unsubscribe()
+
+	select {
+	case <-handled:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Unsubscribe did not unblock the send")
+	}
+}
+
+func TestChannelInterfaceSubscriptionClosed(t *testing.T) {
+	ci := newChannelInterface(FilterNone, ChannelInterfaceConfig{}.withDefaults())
+	s := ci.Subscribe(4, OverflowBlock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ci.cancel = cancel
+
+	go ci.run(ctx, replay([][]byte{CompressFrame(commodity2Fixture)}))
+
+	var received int
+
+	for range s.C {
+		received++
+	}
+
+	if received != 1 {
+		t.Errorf("Expected 1 message before C was closed, got %d", received)
+	}
+
+	// Subscribing once closed returns a closed subscription.
+	closed := ci.Subscribe(1, OverflowBlock)
+
+	if _, ok := <-closed.C; ok {
+		t.Errorf("Expected a closed subscription")
+	}
+
+	closed.Unsubscribe()
+	s.Unsubscribe()
+}
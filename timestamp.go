@@ -0,0 +1,126 @@
+package EDDNClient
+
+import (
+	"fmt"
+	"time"
+)
+
+// timestampLayouts are the layouts EDDN timestamps have been seen in.  The
+// fractional seconds variants are handled by time.Parse automatically.
+var timestampLayouts = [...]string{
+	time.RFC3339,
+	"2006-01-02T15:04:05", // No zone is assumed to be UTC.
+}
+
+// parseTimestamp parses a timestamp as sent by EDDN.  Both the 'Z', and
+// offset zone variants are handled, with or without fractional seconds.
+func parseTimestamp(timestamp string) (t time.Time, err error) {
+	for _, layout := range timestampLayouts {
+		t, err = time.Parse(layout, timestamp)
+
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, err
+}
+
+// Timestamp parses the GatewayTimestamp of the header.
+func (h Header) Timestamp() (time.Time, error) {
+	return parseTimestamp(h.GatewayTimestamp)
+}
+
+// The EventTime methods parse the timestamp of each message, which is when
+// the data was read from the game rather than when EDDN received it.  They
+// differ by however long the uploader held on to the data, which may be
+// hours for uploaders that work through a journal after the fact.
+
+// EventTime parses the Timestamp of the market.
+func (c Commodity) EventTime() (time.Time, error) {
+	return parseTimestamp(c.Message.Timestamp)
+}
+
+// EventTime parses the Timestamp of the market.
+func (c Commodity1) EventTime() (time.Time, error) {
+	return parseTimestamp(c.Message.Timestamp)
+}
+
+// EventTime parses the Timestamp of the market.
+func (c Commodity2) EventTime() (time.Time, error) {
+	return parseTimestamp(c.Message.Timestamp)
+}
+
+// EventTime parses the Timestamp of the blackmarket.
+func (b Blackmarket) EventTime() (time.Time, error) {
+	return parseTimestamp(b.Message.Timestamp)
+}
+
+// EventTime parses the Timestamp of the outfitting.
+func (o Outfitting) EventTime() (time.Time, error) {
+	return parseTimestamp(o.Message.Timestamp)
+}
+
+// EventTime parses the Timestamp of the outfitting.
+func (o Outfitting1) EventTime() (time.Time, error) {
+	return parseTimestamp(o.Message.Timestamp)
+}
+
+// EventTime parses the Timestamp of the shipyard.
+func (s Shipyard) EventTime() (time.Time, error) {
+	return parseTimestamp(s.Message.Timestamp)
+}
+
+// EventTime parses the Timestamp of the shipyard.
+func (s Shipyard1) EventTime() (time.Time, error) {
+	return parseTimestamp(s.Message.Timestamp)
+}
+
+// EventTime parses the Timestamp of the journal event.  Events decoded by a
+// JournalEventDecoder registered by the receiver aren't a JournalEvent, and
+// so return an error.
+func (j Journal) EventTime() (time.Time, error) {
+	event, ok := j.Event()
+
+	if !ok {
+		return time.Time{}, fmt.Errorf("journal message %T has no timestamp",
+			j.Message)
+	}
+
+	return event.EventTime()
+}
+
+// EventTime parses the Timestamp of the approach.
+func (a ApproachSettlement) EventTime() (time.Time, error) {
+	return parseTimestamp(a.Message.Timestamp)
+}
+
+// EventTime parses the Timestamp of the route.
+func (n NavRoute) EventTime() (time.Time, error) {
+	return parseTimestamp(n.Message.Timestamp)
+}
+
+// EventTime parses the Timestamp of the signals.
+func (f FSSSignalDiscovered) EventTime() (time.Time, error) {
+	return parseTimestamp(f.Message.Timestamp)
+}
+
+// EventTime parses the Timestamp of the codex entry.
+func (c CodexEntry) EventTime() (time.Time, error) {
+	return parseTimestamp(c.Message.Timestamp)
+}
+
+// EventTime parses the Timestamp of the signals.
+func (f FSSBodySignals) EventTime() (time.Time, error) {
+	return parseTimestamp(f.Message.Timestamp)
+}
+
+// EventTime parses the Timestamp of the barycentre scan.
+func (s ScanBaryCentre) EventTime() (time.Time, error) {
+	return parseTimestamp(s.Message.Timestamp)
+}
+
+// EventTime parses the Timestamp of the materials.
+func (f FCMaterials) EventTime() (time.Time, error) {
+	return parseTimestamp(f.Message.Timestamp)
+}
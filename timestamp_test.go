@@ -0,0 +1,97 @@
+package EDDNClient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHeaderTimestamp(t *testing.T) {
+	expected := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		timestamp string
+		expected  time.Time
+	}{
+		{"2017-01-02T03:04:05Z", expected},
+		{"2017-01-02T03:04:05+00:00", expected},
+		{"2017-01-02T05:04:05+02:00", expected},
+		{"2017-01-02T03:04:05", expected},
+		{"2017-01-02T03:04:05.123456Z", expected.Add(123456 * time.Microsecond)},
+		{"2017-01-02T03:04:05.5+00:00", expected.Add(500 * time.Millisecond)},
+	}
+
+	for _, test := range tests {
+		got, err := Header{GatewayTimestamp: test.timestamp}.Timestamp()
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.timestamp, err)
+			continue
+		}
+
+		if !got.Equal(test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.timestamp, test.expected,
+				got)
+		}
+	}
+}
+
+func TestHeaderTimestampInvalid(t *testing.T) {
+	for _, timestamp := range []string{"", "yesterday", "2017-01-02"} {
+		if _, err := (Header{GatewayTimestamp: timestamp}).Timestamp(); err == nil {
+			t.Errorf("%q: expected error", timestamp)
+		}
+	}
+}
+
+func TestEventTime(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		expected time.Time
+	}{
+		{commodity2Fixture, time.Date(2015, 6, 1, 12, 34, 56, 0, time.UTC)},
+		{outfitting1Fixture, time.Date(2015, 6, 1, 12, 34, 56, 0, time.UTC)},
+		{shipyard1Fixture, time.Date(2015, 6, 1, 12, 34, 56, 0, time.UTC)},
+		{approachSettlementFixture, time.Date(2021, 5, 25, 18, 20, 30, 0, time.UTC)},
+		{navRouteFixture, time.Date(2021, 5, 25, 18, 30, 2, 0, time.UTC)},
+		{fssSignalDiscoveredFixture, time.Date(2021, 5, 25, 18, 40, 11, 0, time.UTC)},
+		{codexEntryFixture, time.Date(2021, 5, 25, 18, 50, 44, 0, time.UTC)},
+		{fssBodySignalsFixture, time.Date(2021, 5, 25, 18, 52, 36, 0, time.UTC)},
+		{scanBaryCentreFixture, time.Date(2021, 5, 25, 18, 55, 2, 0, time.UTC)},
+		{fcMaterialsJournalFixture, time.Date(2022, 9, 22, 12, 41, 6, 0, time.UTC)},
+		{journalFixture(`{"timestamp": "2017-01-01T12:00:00Z", "event": "FSDJump",
+			"StarSystem": "Pleione", "StarPos": [-77.0, -146.78125, -344.125]}`),
+			time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		result, err := parseJSON(test.fixture)
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", result.SchemaRef, err)
+			continue
+		}
+
+		msg, ok := result.Message.(interface {
+			EventTime() (time.Time, error)
+		})
+
+		if !ok {
+			t.Errorf("%T has no EventTime", result.Message)
+			continue
+		}
+
+		got, err := msg.EventTime()
+
+		if err != nil {
+			t.Errorf("%T: unexpected error: %v", result.Message, err)
+		} else if !got.Equal(test.expected) {
+			t.Errorf("%T: expected %v, got %v", result.Message, test.expected, got)
+		}
+	}
+}
+
+func TestJournalEventTimeCustomEvent(t *testing.T) {
+	if _, err := (Journal{Message: map[string]interface{}{}}).EventTime(); err == nil {
+		t.Errorf("Expected an error for a message that isn't a JournalEvent")
+	}
+}
@@ -0,0 +1,97 @@
+package EDDNClient
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	errReceiveTimeout = errors.New("nothing received within the receive timeout")
+)
+
+// transport is a connection to a relay that frames are received from.
+type transport interface {
+	// recv returns the next frame from the relay, or errReceiveTimeout if
+	// nothing was received within the ReceiveTimeout.
+	recv() (frame string, err error)
+
+	// close closes the connection to the relay.
+	close() error
+}
+
+// prefixSubscriber is implemented by transports that filter the frames they
+// receive by a subscription prefix.
+type prefixSubscriber interface {
+	// subscribe replaces the subscription to previous with prefix.
+	subscribe(prefix string, previous string) error
+}
+
+// isWebsocket reports whether address is a websocket relay.  i.e. one
+// bridging the ZeroMQ relay for networks that can't reach it directly.
+func isWebsocket(address string) bool {
+	address = strings.ToLower(address)
+
+	return strings.HasPrefix(address, "ws://") || strings.HasPrefix(address, "wss://")
+}
+
+// dial connects to the relay in config, using a websocket for "ws://", and
+// "wss://" addresses, and ZeroMQ for anything else.  The Source of config is
+// used instead when it's set.
+func dial(config ChannelInterfaceConfig) (conn transport, err error) {
+	if config.Source != nil {
+		return sourceTransport{config.Source}, nil
+	}
+
+	if isWebsocket(config.Address) {
+		ws, err := dialWebsocket(config)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return ws, nil
+	}
+
+	return dialZMQ(config)
+}
+
+// SetSubscriptionPrefix replaces the SubscriptionPrefix of ci, which is
+// applied before the next message is received, and whenever it reconnects.
+// Messages already queued by ZeroMQ under the old prefix are still received.
+// Passing an empty prefix receives every message.
+func (ci *ChannelInterface) SetSubscriptionPrefix(prefix string) {
+	ci.filterLock.Lock()
+	defer ci.filterLock.Unlock()
+
+	ci.subscriptionPrefix = prefix
+}
+
+// currentSubscriptionPrefix returns the prefix last given to
+// SetSubscriptionPrefix.
+func (ci *ChannelInterface) currentSubscriptionPrefix() string {
+	ci.filterLock.RLock()
+	defer ci.filterLock.RUnlock()
+
+	return ci.subscriptionPrefix
+}
+
+// updateSubscription subscribes the socket of ci to the prefix last given to
+// SetSubscriptionPrefix if it has changed.  Sockets aren't safe to use from
+// more than one goroutine, so this is only called from the ChannelInterface
+// goroutine.
+func (ci *ChannelInterface) updateSubscription() {
+	prefix := ci.currentSubscriptionPrefix()
+
+	if prefix == ci.subscribed {
+		return
+	}
+
+	if t, ok := ci.conn.(prefixSubscriber); ok {
+		if err := t.subscribe(prefix, ci.subscribed); err != nil {
+			logf("Error: %v", err)
+			return
+		}
+	}
+
+	ci.subscribed = prefix
+}
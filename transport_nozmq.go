@@ -0,0 +1,25 @@
+//go:build !cgo || nozmq
+
+package EDDNClient
+
+import (
+	"errors"
+)
+
+var (
+	errNoZMQ = errors.New("built without ZeroMQ support; use a websocket relay, or a Source")
+)
+
+// zmqSocket is the type of ChannelInterface.Socket, which is always nil when
+// built without ZeroMQ.
+type zmqSocket struct{}
+
+// dialZMQ fails, as there's no ZeroMQ to connect to the relay in config with.
+func dialZMQ(config ChannelInterfaceConfig) (conn transport, err error) {
+	return nil, errNoZMQ
+}
+
+// socketOf returns nil, as conn is never a ZeroMQ relay.
+func socketOf(conn transport) *zmqSocket {
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build !cgo || nozmq
+
+package EDDNClient
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestChannelInterfaceNoZMQ(t *testing.T) {
+	_, err := NewChannelInterfaceWithConfig(FilterNone,
+		ChannelInterfaceConfig{Address: "tcp://127.0.0.1:59500"})
+
+	if !errors.Is(err, errNoZMQ) {
+		t.Errorf("Expected errNoZMQ, got %v", err)
+	}
+}
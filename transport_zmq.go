@@ -0,0 +1,99 @@
+//go:build cgo && !nozmq
+
+package EDDNClient
+
+import (
+	"fmt"
+	zmq "github.com/pebbe/zmq4"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// zmqSocket is the type of ChannelInterface.Socket.
+type zmqSocket = zmq.Socket
+
+// zmqTransport is a transport receiving frames from a ZeroMQ relay.
+type zmqTransport struct {
+	socket *zmq.Socket
+}
+
+// recv returns the last part of the next message from the relay.  EDDN only
+// sends single part messages, but relays publishing under a topic often send
+// it as a separate part before the message itself.
+func (t zmqTransport) recv() (frame string, err error) {
+	parts, err := t.socket.RecvMessage(0)
+
+	if zmq.AsErrno(err) == zmq.Errno(syscall.EAGAIN) {
+		return "", errReceiveTimeout
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return parts[len(parts)-1], nil
+}
+
+func (t zmqTransport) close() error {
+	return t.socket.Close()
+}
+
+func (t zmqTransport) subscribe(prefix string, previous string) error {
+	if err := t.socket.SetSubscribe(prefix); err != nil {
+		return err
+	}
+
+	return t.socket.SetUnsubscribe(previous)
+}
+
+// dialZMQ connects to the ZeroMQ relay in config.
+func dialZMQ(config ChannelInterfaceConfig) (conn transport, err error) {
+	subscriber, err := newSubscriber(config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return zmqTransport{subscriber}, nil
+}
+
+// socketOf returns the socket of conn, or nil if it isn't a ZeroMQ relay.
+func socketOf(conn transport) *zmqSocket {
+	if t, ok := conn.(zmqTransport); ok {
+		return t.socket
+	}
+
+	return nil
+}
+
+// newSubscriber creates a ZeroMQ subscriber connected to the relay in config.
+func newSubscriber(config ChannelInterfaceConfig) (subscriber *zmq.Socket, err error) {
+	subscriber, err = zmq.NewSocket(zmq.SUB)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if config.SocksProxy != "" {
+		proxy := strings.TrimPrefix(config.SocksProxy, "socks5://")
+
+		if err = subscriber.SetSocksProxy(proxy); err != nil {
+			subscriber.Close()
+			return nil, fmt.Errorf("setting SOCKS proxy: %w", err)
+		}
+	}
+
+	if err = subscriber.Connect(config.endpoint()); err != nil {
+		subscriber.Close()
+		return nil, err
+	}
+
+	subscriber.SetSubscribe(config.SubscriptionPrefix)
+	subscriber.SetConnectTimeout(time.Duration(600000))
+	subscriber.SetHeartbeatIvl(500 * time.Millisecond)
+	subscriber.SetTcpKeepalive(1)
+	subscriber.SetRcvtimeo(config.ReceiveTimeout)
+
+	return subscriber, nil
+}
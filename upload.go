@@ -8,7 +8,6 @@ import (
 	"github.com/xeipuuv/gojsonschema"
 	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"time"
 )
@@ -169,10 +168,10 @@ func validateMessage(schema *gojsonschema.Schema, data interface{}) (err error)
 	}
 
 	if !result.Valid() {
-		log.Printf("The document is not valid. see errors :\n")
+		logf("The document is not valid. see errors :\n")
 		for _, err := range result.Errors() {
 			// Err implements the ResultError interface
-			log.Printf("- %s\n", err)
+			logf("- %s\n", err)
 		}
 
 		return errors.New("error validating message")
@@ -0,0 +1,94 @@
+package EDDNClient
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ValidationError is returned when Validate is set and a message is missing
+// fields its schema requires, or has them set to null or an empty string.
+type ValidationError struct {
+	SchemaRef string   // The schema of the message
+	Missing   []string // The missing, or empty required fields
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("message using %s is missing required fields: %s",
+		e.SchemaRef, strings.Join(e.Missing, ", "))
+}
+
+// requiredFields contains the top level fields of the message each schema
+// requires, keyed by schema name and version.  It only covers the built-in
+// schemas, so messages using a schema registered by the receiver are never
+// rejected.
+var requiredFields = map[string][]string{
+	"commodity/1": {"systemName", "stationName", "itemName", "sellPrice",
+		"timestamp"},
+	"commodity/2":  {"systemName", "stationName", "timestamp", "commodities"},
+	"commodity/3":  {"systemName", "stationName", "timestamp", "commodities"},
+	"journal/1":    {"timestamp", "event", "StarSystem", "StarPos"},
+	"outfitting/1": {"systemName", "stationName", "timestamp", "modules"},
+	"outfitting/2": {"systemName", "stationName", "timestamp", "modules"},
+	"blackmarket/1": {"systemName", "stationName", "timestamp", "name",
+		"sellPrice", "prohibited"},
+	"shipyard/1": {"systemName", "timestamp", "ships"},
+	"shipyard/2": {"systemName", "stationName", "timestamp", "ships"},
+	"approachsettlement/1": {"timestamp", "event", "StarSystem", "StarPos",
+		"SystemAddress", "Name", "BodyID", "BodyName"},
+	"navroute/1": {"timestamp", "event", "Route"},
+	"fsssignaldiscovered/1": {"timestamp", "event", "StarSystem", "StarPos",
+		"SystemAddress", "signals"},
+	"codexentry/1": {"timestamp", "event", "System", "StarPos",
+		"SystemAddress", "EntryID", "Name", "Region", "Category",
+		"SubCategory"},
+	"fssbodysignals/1": {"timestamp", "event", "StarSystem", "StarPos",
+		"SystemAddress", "BodyID", "BodyName", "Signals"},
+	"scanbarycentre/1": {"timestamp", "event", "StarSystem", "StarPos",
+		"SystemAddress", "BodyID"},
+	"fcmaterials_journal/1": {"timestamp", "event", "MarketID", "CarrierID",
+		"Items"},
+	"fcmaterials_capi/1": {"timestamp", "event", "MarketID", "CarrierID",
+		"Items"},
+}
+
+// checkRequiredFields checks that jsonData contains every field required by
+// its schema.  Messages using a schema without a list of required fields are
+// always valid.
+func checkRequiredFields(jsonData Root) error {
+	name, version, err := schemaKey(jsonData.SchemaRef)
+
+	if err != nil {
+		return err
+	}
+
+	required, ok := requiredFields[fmt.Sprintf("%s/%d", name, version)]
+
+	if !ok {
+		return nil
+	}
+
+	if isJSONArray(jsonData.Message) {
+		return &BatchMessageError{jsonData.SchemaRef}
+	}
+
+	var fields map[string]interface{}
+
+	if err := json.Unmarshal(jsonData.Message, &fields); err != nil {
+		return fmt.Errorf("%s: %w", jsonData.SchemaRef, err)
+	}
+
+	var missing []string
+
+	for _, field := range required {
+		if value, ok := fields[field]; !ok || value == nil || value == "" {
+			missing = append(missing, field)
+		}
+	}
+
+	if len(missing) > 0 {
+		return &ValidationError{SchemaRef: jsonData.SchemaRef, Missing: missing}
+	}
+
+	return nil
+}
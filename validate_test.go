@@ -0,0 +1,65 @@
+package EDDNClient
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseValidate(t *testing.T) {
+	options := ParseOptions{Validate: true}
+
+	if _, err := ParseMessageWithOptions([]byte(compress(t, commodity2Fixture)),
+		options); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	fixture := strings.Replace(commodity2Fixture, `"Azeban Orbital"`, `""`, 1)
+
+	// Without Validate incomplete messages are still decoded.
+	if _, err := ParseMessage([]byte(compress(t, fixture))); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	_, err := ParseMessageWithOptions([]byte(compress(t, fixture)), options)
+
+	var invalid *ValidationError
+
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Expected ValidationError, got %v", err)
+	}
+
+	if len(invalid.Missing) != 1 || invalid.Missing[0] != "stationName" {
+		t.Errorf("Unexpected missing fields: %v", invalid.Missing)
+	}
+}
+
+func TestParseValidateUnknownSchema(t *testing.T) {
+	fixture := strings.Replace(commodity2Fixture, "commodity/2", "commodity/4", 1)
+
+	_, err := ParseMessageWithOptions([]byte(fixture), ParseOptions{Validate: true})
+
+	var unsupported *UnsupportedSchemaError
+
+	if !errors.As(err, &unsupported) {
+		t.Errorf("Expected UnsupportedSchemaError, got %v", err)
+	}
+}
+
+func TestCheckRequiredFields(t *testing.T) {
+	root := Root{
+		SchemaRef: "https://eddn.edcd.io/schemas/navroute/1",
+		Message:   []byte(`{"event": "NavRoute", "timestamp": null}`)}
+
+	err := checkRequiredFields(root)
+
+	var invalid *ValidationError
+
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Expected ValidationError, got %v", err)
+	}
+
+	if strings.Join(invalid.Missing, ",") != "timestamp,Route" {
+		t.Errorf("Unexpected missing fields: %v", invalid.Missing)
+	}
+}
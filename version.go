@@ -0,0 +1,103 @@
+package EDDNClient
+
+import (
+	"strconv"
+	"strings"
+)
+
+// softwareVersion is a version parsed by parseVersion.
+type softwareVersion struct {
+	numbers    []int
+	prerelease string
+}
+
+// parseVersion parses a dotted version such as "5.1.0", "v2.3", or
+// "1.0.0-beta.2+build5".  Any number of numeric components are accepted
+// as not every tool sending to EDDN uses semver.  ok is false if version
+// isn't in this form.
+func parseVersion(version string) (parsed softwareVersion, ok bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+
+	// Build metadata has no bearing on precedence.
+	if i := strings.IndexByte(version, '+'); i >= 0 {
+		version = version[:i]
+	}
+
+	if i := strings.IndexByte(version, '-'); i >= 0 {
+		parsed.prerelease = version[i+1:]
+		version = version[:i]
+
+		if parsed.prerelease == "" {
+			return softwareVersion{}, false
+		}
+	}
+
+	for _, part := range strings.Split(version, ".") {
+		number, err := strconv.Atoi(part)
+
+		if err != nil || number < 0 {
+			return softwareVersion{}, false
+		}
+
+		parsed.numbers = append(parsed.numbers, number)
+	}
+
+	return parsed, true
+}
+
+// compare returns -1, 0, or 1 if v is less than, equal to, or greater than
+// other.  Missing components are treated as 0 so "1.2" equals "1.2.0".  A
+// pre-release is less than the release it precedes, and pre-releases of the
+// same version are compared as strings.
+func (v softwareVersion) compare(other softwareVersion) int {
+	for i := 0; i < len(v.numbers) || i < len(other.numbers); i++ {
+		var a, b int
+
+		if i < len(v.numbers) {
+			a = v.numbers[i]
+		}
+
+		if i < len(other.numbers) {
+			b = other.numbers[i]
+		}
+
+		if a != b {
+			if a < b {
+				return -1
+			}
+
+			return 1
+		}
+	}
+
+	switch {
+	case v.prerelease == other.prerelease:
+		return 0
+	case v.prerelease == "":
+		return 1
+	case other.prerelease == "":
+		return -1
+	}
+
+	return strings.Compare(v.prerelease, other.prerelease)
+}
+
+// VersionAtLeast reports whether the SoftwareVersion of h is min or later.
+// Both are parsed as dotted versions, with an optional "v" prefix and semver
+// pre-release, and build suffixes.  i.e. "5.1.0", or "v1.0.0-beta.2".  If
+// either can't be parsed false is returned.
+func (h Header) VersionAtLeast(min string) bool {
+	version, ok := parseVersion(h.SoftwareVersion)
+
+	if !ok {
+		return false
+	}
+
+	minVersion, ok := parseVersion(min)
+
+	if !ok {
+		return false
+	}
+
+	return version.compare(minVersion) >= 0
+}
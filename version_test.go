@@ -0,0 +1,38 @@
+package EDDNClient
+
+import (
+	"testing"
+)
+
+func TestHeaderVersionAtLeast(t *testing.T) {
+	tests := []struct {
+		version string
+		min     string
+		want    bool
+	}{
+		{"5.1.0", "5.1.0", true},
+		{"5.1.1", "5.1.0", true},
+		{"5.10.0", "5.9.3", true},
+		{"5.0.9", "5.1.0", false},
+		{"v2.3", "2.3.0", true},
+		{"4.1.0.0", "4.1", true},
+		{"1.0.0-beta.2", "1.0.0", false},
+		{"1.0.0", "1.0.0-beta.2", true},
+		{"1.0.0-beta.2", "1.0.0-beta.1", true},
+		{"1.0.0+build5", "1.0.0", true},
+		{"", "1.0.0", false},
+		{"Release 5", "1.0.0", false},
+		{"1..2", "1.0.0", false},
+		{"1.0.0-", "1.0.0", false},
+		{"5.1.0", "not a version", false},
+	}
+
+	for _, test := range tests {
+		h := Header{SoftwareVersion: test.version}
+
+		if got := h.VersionAtLeast(test.min); got != test.want {
+			t.Errorf("Header{SoftwareVersion: %q}.VersionAtLeast(%q) = %v, want %v",
+				test.version, test.min, got, test.want)
+		}
+	}
+}
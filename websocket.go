@@ -0,0 +1,313 @@
+package EDDNClient
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// websocketGUID is appended to the key of a websocket handshake by the server
+// to prove it understood the request.  See RFC 6455 section 1.3.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// websocketDialTimeout bounds how long connecting to a websocket relay, and
+// completing the handshake may take.
+const websocketDialTimeout = 30 * time.Second
+
+// Websocket opcodes.  See RFC 6455 section 5.2.
+const (
+	websocketContinuation = 0x0
+	websocketText         = 0x1
+	websocketBinary       = 0x2
+	websocketClose        = 0x8
+	websocketPing         = 0x9
+	websocketPong         = 0xa
+)
+
+var (
+	errWebsocketClosed = errors.New("websocket closed by the relay")
+)
+
+// websocketTransport is a transport receiving frames from a websocket relay.
+// Each binary, or text message is a single frame exactly as the ZeroMQ relay
+// would send it.  Only what's needed to receive messages is implemented.
+type websocketTransport struct {
+	conn           net.Conn
+	reader         *bufio.Reader
+	receiveTimeout time.Duration
+	idleTimeout    time.Duration
+	maxSize        int64
+}
+
+// dialWebsocket connects to the websocket relay in config, and completes the
+// handshake.
+func dialWebsocket(config ChannelInterfaceConfig) (t *websocketTransport, err error) {
+	u, err := url.Parse(config.Address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	secure := strings.EqualFold(u.Scheme, "wss")
+	host := u.Host
+
+	if u.Port() == "" {
+		if secure {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: websocketDialTimeout}
+
+	if ip := net.ParseIP(config.SourceAddress); ip != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+
+	conn, err := dialer.Dial("tcp", host)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if secure {
+		tlsConfig := config.TLSConfig.Clone()
+
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = u.Hostname()
+		}
+
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	t = &websocketTransport{
+		conn:           conn,
+		reader:         bufio.NewReader(conn),
+		receiveTimeout: config.ReceiveTimeout,
+		idleTimeout:    config.IdleTimeout,
+		maxSize:        config.MaxMessageSize}
+
+	conn.SetDeadline(time.Now().Add(websocketDialTimeout))
+
+	if err = t.handshake(u); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("websocket handshake with %s: %w", u.Host, err)
+	}
+
+	conn.SetDeadline(time.Time{})
+
+	return t, nil
+}
+
+// handshake upgrades the connection of t to a websocket.
+func (t *websocketTransport) handshake(u *url.URL) error {
+	nonce := make([]byte, 16)
+
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	// The request URL must be http(s) for net/http to write it.
+	requestURL := *u
+	requestURL.Scheme = strings.Replace(strings.ToLower(u.Scheme), "ws", "http", 1)
+
+	req, err := http.NewRequest(http.MethodGet, requestURL.String(), nil)
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+
+	if err = req.Write(t.conn); err != nil {
+		return err
+	}
+
+	resp, err := http.ReadResponse(t.reader, req)
+
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	sum := sha1.Sum([]byte(key + websocketGUID))
+
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		return errors.New("invalid Sec-WebSocket-Accept")
+	}
+
+	return nil
+}
+
+func (t *websocketTransport) recv() (frame string, err error) {
+	var message []byte
+
+	for {
+		// Only the wait for a new frame is bound by the receive timeout.
+		// Once it has started arriving the rest of it is waited for up to
+		// the idle timeout so a timeout never leaves half a frame unread.
+		t.conn.SetReadDeadline(time.Now().Add(t.receiveTimeout))
+
+		if _, err = t.reader.Peek(1); err != nil {
+			var netErr net.Error
+
+			if errors.As(err, &netErr) && netErr.Timeout() && message == nil {
+				return "", errReceiveTimeout
+			}
+
+			return "", err
+		}
+
+		t.conn.SetReadDeadline(time.Now().Add(t.idleTimeout))
+
+		fin, opcode, payload, err := t.readFrame()
+
+		if err != nil {
+			return "", err
+		}
+
+		switch opcode {
+		case websocketPing:
+			if err = t.writeFrame(websocketPong, payload); err != nil {
+				return "", err
+			}
+
+			continue
+
+		case websocketPong:
+			continue
+
+		case websocketClose:
+			return "", errWebsocketClosed
+
+		case websocketText, websocketBinary, websocketContinuation:
+			message = append(message, payload...)
+
+			if int64(len(message)) > t.maxSize {
+				return "", &MessageTooLargeError{t.maxSize}
+			}
+		}
+
+		if fin {
+			return string(message), nil
+		}
+	}
+}
+
+// readFrame reads a single websocket frame, unmasking the payload if needed.
+func (t *websocketTransport) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+
+	if _, err = io.ReadFull(t.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7f)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+
+		if _, err = io.ReadFull(t.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+
+	case 127:
+		var ext [8]byte
+
+		if _, err = io.ReadFull(t.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if length > uint64(t.maxSize) {
+		return false, 0, nil, &MessageTooLargeError{t.maxSize}
+	}
+
+	var mask [4]byte
+
+	if masked {
+		if _, err = io.ReadFull(t.reader, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload = make([]byte, length)
+
+	if _, err = io.ReadFull(t.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// writeFrame writes a single control frame.  Frames sent by a client must be
+// masked, and control frames carry at most 125 bytes.
+func (t *websocketTransport) writeFrame(opcode byte, payload []byte) error {
+	if len(payload) > 125 {
+		payload = payload[:125]
+	}
+
+	frame := make([]byte, 6, 6+len(payload))
+	frame[0] = 0x80 | opcode
+	frame[1] = 0x80 | byte(len(payload))
+
+	if _, err := rand.Read(frame[2:6]); err != nil {
+		return err
+	}
+
+	for i, b := range payload {
+		frame = append(frame, b^frame[2+i%4])
+	}
+
+	_, err := t.conn.Write(frame)
+
+	return err
+}
+
+func (t *websocketTransport) close() error {
+	// Let the relay know, but don't wait for it to answer.
+	t.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	t.writeFrame(websocketClose, nil)
+
+	return t.conn.Close()
+}
@@ -0,0 +1,201 @@
+package EDDNClient
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeServerFrame writes an unmasked websocket frame, as a server would.
+func writeServerFrame(w io.Writer, fin bool, opcode byte, payload []byte) error {
+	header := []byte{opcode, 0}
+
+	if fin {
+		header[0] |= 0x80
+	}
+
+	switch {
+	case len(payload) < 126:
+		header[1] = byte(len(payload))
+	case len(payload) <= 0xffff:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(len(payload)))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(len(payload)))
+	}
+
+	_, err := w.Write(append(header, payload...))
+
+	return err
+}
+
+// websocketRelay returns a handler completing the websocket handshake, and
+// then calling serve with the connection.  Anything the client sends is
+// discarded once serve returns until the client disconnects.
+func websocketRelay(t *testing.T, serve func(conn net.Conn, rw *bufio.ReadWriter)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" ||
+			r.Header.Get("Sec-WebSocket-Version") != "13" {
+			http.Error(w, "not a websocket", http.StatusBadRequest)
+			return
+		}
+
+		conn, rw, err := w.(http.Hijacker).Hijack()
+
+		if err != nil {
+			t.Errorf("Hijack: %v", err)
+			return
+		}
+
+		defer conn.Close()
+
+		sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + websocketGUID))
+
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+			"Upgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) +
+			"\r\n\r\n")
+		rw.Flush()
+
+		serve(conn, rw)
+
+		io.Copy(io.Discard, rw)
+	})
+}
+
+func receiveCommodities(t *testing.T, ci *ChannelInterface, n int) []Commodity {
+	var commodities []Commodity
+
+	timeout := time.After(5 * time.Second)
+
+	for len(commodities) < n {
+		select {
+		case msg := <-ci.CommodityChan:
+			commodities = append(commodities, msg)
+		case <-timeout:
+			t.Fatalf("Expected %d commodities, got %d", n, len(commodities))
+		}
+	}
+
+	return commodities
+}
+
+func TestWebsocketChannelInterface(t *testing.T) {
+	frame := CompressFrame(commodity2Fixture)
+	pong := make(chan []byte, 1)
+
+	server := httptest.NewServer(websocketRelay(t, func(conn net.Conn, rw *bufio.ReadWriter) {
+		writeServerFrame(rw, true, websocketPing, []byte("ping"))
+
+		// The same message fragmented, and then whole.
+		writeServerFrame(rw, false, websocketBinary, frame[:10])
+		writeServerFrame(rw, true, websocketContinuation, frame[10:])
+		writeServerFrame(rw, true, websocketBinary, frame)
+		rw.Flush()
+
+		transport := &websocketTransport{conn: conn, reader: rw.Reader,
+			receiveTimeout: 5 * time.Second, idleTimeout: 5 * time.Second,
+			maxSize: DefaultMaxMessageSize}
+
+		if _, opcode, payload, err := transport.readFrame(); err == nil &&
+			opcode == websocketPong {
+			pong <- payload
+		}
+	}))
+
+	defer server.Close()
+
+	ci, err := NewChannelInterfaceWithConfig(FilterNone, ChannelInterfaceConfig{
+		Address: "ws" + strings.TrimPrefix(server.URL, "http")})
+
+	if err != nil {
+		t.Fatalf("NewChannelInterfaceWithConfig: %v", err)
+	}
+
+	defer ci.Close()
+
+	if ci.Socket != nil {
+		t.Errorf("Expected no ZeroMQ socket for a websocket relay")
+	}
+
+	commodities := receiveCommodities(t, ci, 2)
+
+	if commodities[0].Message.SystemName != commodities[1].Message.SystemName {
+		t.Errorf("Unexpected commodities: %+v", commodities)
+	}
+
+	select {
+	case payload := <-pong:
+		if string(payload) != "ping" {
+			t.Errorf("Expected pong with \"ping\", got %q", payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("No pong received")
+	}
+}
+
+func TestWebsocketChannelInterfaceTLS(t *testing.T) {
+	server := httptest.NewTLSServer(websocketRelay(t, func(conn net.Conn, rw *bufio.ReadWriter) {
+		writeServerFrame(rw, true, websocketBinary, CompressFrame(commodity2Fixture))
+		rw.Flush()
+	}))
+
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	ci, err := NewChannelInterfaceWithConfig(FilterNone, ChannelInterfaceConfig{
+		Address:   "wss" + strings.TrimPrefix(server.URL, "https"),
+		TLSConfig: &tls.Config{RootCAs: roots}})
+
+	if err != nil {
+		t.Fatalf("NewChannelInterfaceWithConfig: %v", err)
+	}
+
+	defer ci.Close()
+
+	receiveCommodities(t, ci, 1)
+}
+
+func TestWebsocketHandshakeRejected(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := NewChannelInterfaceWithConfig(FilterNone, ChannelInterfaceConfig{
+		Address: "ws" + strings.TrimPrefix(server.URL, "http")})
+
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected the handshake to fail with 404, got %v", err)
+	}
+}
+
+func TestWebsocketMessageTooLarge(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	go func() {
+		defer server.Close()
+		writeServerFrame(server, true, websocketBinary, make([]byte, 200))
+	}()
+
+	transport := &websocketTransport{conn: client, reader: bufio.NewReader(client),
+		receiveTimeout: time.Second, idleTimeout: time.Second, maxSize: 100}
+
+	if _, err := transport.recv(); err == nil {
+		t.Errorf("Expected an error for a frame larger than the limit")
+	} else if _, ok := err.(*MessageTooLargeError); !ok {
+		t.Errorf("Expected a MessageTooLargeError, got %v", err)
+	}
+}